@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/backfill"
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/config"
+)
+
+// runBackfill implements the backfill subcommand
+func runBackfill(args []string) {
+	flags := flag.NewFlagSet("backfill", flag.ExitOnError)
+	configFile := flags.String("config.file", "config.yml", "Path to the configuration file")
+	dataDir := flags.String("data.dir", "data", "Directory where the bot state is persisted")
+	profileCacheTTL := flags.Duration("cache.profile.ttl", 15*time.Minute, "How long Leetify profiles are reused from the cache by consecutive backfills (0 to disable)")
+	flags.Parse(args)
+
+	if len(*dataDir) == 0 {
+		log.Fatal("Backfill: A data directory is required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg := config.MustLoadConfig(*configFile)
+	resolvePlayers(ctx, cfg, newVanityCache(*dataDir), nil)
+	matchHistory := openHistory(*dataDir)
+	if matchHistory == nil {
+		log.Fatal("Backfill: The match history could not be loaded")
+	}
+	backfiller := backfill.NewBackfiller(newSources(cfg, newLeetifyCache(*dataDir, *profileCacheTTL, clock.Real()), clock.Real()).Sources(), matchHistory, cfg.Players)
+	result, err := backfiller.Run(ctx)
+	log.Printf("Backfill: %d imported, %d already in history, %d failed", result.Imported, result.Skipped, result.Failed)
+	if err != nil {
+		log.Fatalf("Backfill: Stopped: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package backfill
+
+import (
+	"context"
+	"log"
+
+	"github.com/mxdc/cs2-discord-bot/config"
+	"github.com/mxdc/cs2-discord-bot/history"
+	"github.com/mxdc/cs2-discord-bot/parser"
+	"github.com/mxdc/cs2-discord-bot/provider"
+)
+
+// Backfiller imports the matches the sources still return for the configured
+// players into the local history, without notifying anything.
+// Games already in the history are skipped, so an interrupted run resumes
+// where it stopped.
+type Backfiller struct {
+	sources []provider.MatchSource
+	history *history.Store
+	players []config.Player
+}
+
+// Result counts the games processed by a run
+type Result struct {
+	Imported int
+	Skipped  int
+	Failed   int
+}
+
+func NewBackfiller(sources []provider.MatchSource, matchHistory *history.Store, players []config.Player) *Backfiller {
+	return &Backfiller{
+		sources: sources,
+		history: matchHistory,
+		players: players,
+	}
+}
+
+// Run imports the matches of every player until done or the context is cancelled
+func (b *Backfiller) Run(ctx context.Context) (Result, error) {
+	var result Result
+
+	for _, source := range b.sources {
+		for _, player := range b.players {
+			if err := b.backfillPlayer(ctx, source, player, &result); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (b *Backfiller) backfillPlayer(ctx context.Context, source provider.MatchSource, player config.Player, result *Result) error {
+	games, err := source.RecentMatches(ctx, player)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		log.Printf("Backfill: %s: Error fetching %s matches: %v", player.PlayerID(), source.Name(), err)
+		return nil
+	}
+
+	log.Printf("Backfill: %s: %d %s matches returned", player.PlayerID(), len(games), source.Name())
+
+	for i, game := range games {
+		if b.history.Has(game.GameID) {
+			result.Skipped++
+			continue
+		}
+
+		details, err := source.MatchDetails(ctx, game)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// Left out of the history, the next run will retry it
+			log.Printf("Backfill: %s: Error fetching match %s: %v", player.PlayerID(), game.GameID, err)
+			result.Failed++
+			continue
+		}
+
+		match := parser.ParseMatchResultWithDetails(game, details, nil, b.players)
+		if err := b.history.Save(match); err != nil {
+			return err
+		}
+
+		result.Imported++
+		log.Printf("Backfill: %s: Imported match %s (%d/%d)", player.PlayerID(), game.GameID, i+1, len(games))
+	}
+
+	return nil
+}
@@ -0,0 +1,305 @@
+// Package banwatch follows the opponents of the tracked players and reports
+// the VAC and game bans they receive after the match.
+package banwatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/parser"
+	"github.com/mxdc/cs2-discord-bot/steam"
+	"github.com/mxdc/cs2-discord-bot/storage"
+)
+
+const watchFileVersion = 1
+
+// MatchRef is a match played against an opponent
+type MatchRef struct {
+	GameID     string    `json:"gameId"`
+	GameMode   string    `json:"gameMode"`
+	MapName    string    `json:"mapName"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Link       string    `json:"link"`
+	OwnScore   int       `json:"ownScore"`
+	EnemyScore int       `json:"enemyScore"`
+}
+
+type opponent struct {
+	Name string `json:"name"`
+	// Matches played against the opponent, oldest first
+	Matches []MatchRef `json:"matches"`
+	// Checked is false until the bans of the opponent have been acknowledged once
+	Checked  bool `json:"checked"`
+	VACBans  int  `json:"vacBans"`
+	GameBans int  `json:"gameBans"`
+}
+
+func (o *opponent) lastMatch() time.Time {
+	if len(o.Matches) == 0 {
+		return time.Time{}
+	}
+	return o.Matches[len(o.Matches)-1].FinishedAt
+}
+
+type watchFile struct {
+	Version   int                  `json:"version"`
+	Opponents map[string]*opponent `json:"opponents"`
+}
+
+// Ban is a ban received by an opponent after a match against a tracked player
+type Ban struct {
+	SteamID  string
+	Name     string
+	VACBans  int
+	GameBans int
+	// NewVAC and NewGame tell which kind of ban is new
+	NewVAC  bool
+	NewGame bool
+	// BannedAt is the day of the latest ban, Steam only gives the number of days since
+	BannedAt time.Time
+	// Matches played against the opponent up to the ban day
+	Matches []MatchRef
+}
+
+// Watcher records the opponents of every match and checks their bans.
+// Bans are announced once: Check keeps reporting a ban until it is passed
+// to Acknowledge. A nil watcher records nothing.
+type Watcher struct {
+	// path of the state file, empty to keep it in memory
+	path      string
+	retention time.Duration
+	clock     clock.Clock
+
+	mu        sync.Mutex
+	opponents map[string]*opponent
+}
+
+// NewWatcher loads the state file at path. Opponents are watched for
+// retention after the last match played against them.
+func NewWatcher(path string, retention time.Duration, clk clock.Clock) (*Watcher, error) {
+	watcher := &Watcher{
+		path:      path,
+		retention: retention,
+		clock:     clk,
+		opponents: map[string]*opponent{},
+	}
+
+	if len(path) == 0 {
+		return watcher, nil
+	}
+
+	var file watchFile
+	err := storage.ReadJSON(path, &file)
+	if errors.Is(err, os.ErrNotExist) {
+		return watcher, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if file.Version != watchFileVersion {
+		return nil, fmt.Errorf("unsupported ban watch version %d in %s", file.Version, path)
+	}
+
+	for steamID, o := range file.Opponents {
+		watcher.opponents[steamID] = o
+	}
+	log.Printf("BanWatch: Watching %d opponents from %s", len(watcher.opponents), path)
+
+	return watcher, nil
+}
+
+// Record adds the enemy team of the match to the watched opponents
+func (w *Watcher) Record(match parser.MatchWithDetails) error {
+	if w == nil || len(match.GameID) == 0 {
+		return nil
+	}
+
+	ref := MatchRef{
+		GameID:     match.GameID,
+		GameMode:   match.GameMode,
+		MapName:    match.MapName,
+		FinishedAt: match.GameFinishedAt,
+		Link:       match.GetMatchLink(),
+		OwnScore:   match.OwnTeam.Score,
+		EnemyScore: match.EnemyTeam.Score,
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, player := range match.EnemyTeam.Players {
+		if len(player.SteamID) == 0 {
+			continue
+		}
+
+		o, found := w.opponents[player.SteamID]
+		if !found {
+			o = &opponent{}
+			w.opponents[player.SteamID] = o
+		}
+		if len(player.Name) > 0 {
+			o.Name = player.Name
+		}
+
+		// A match is recorded again when its details are updated
+		i := slices.IndexFunc(o.Matches, func(m MatchRef) bool { return m.GameID == ref.GameID })
+		if i >= 0 {
+			o.Matches[i] = ref
+		} else {
+			o.Matches = append(o.Matches, ref)
+		}
+		slices.SortFunc(o.Matches, func(a, b MatchRef) int { return a.FinishedAt.Compare(b.FinishedAt) })
+	}
+
+	return w.save()
+}
+
+// Check fetches the bans of every watched opponent and returns the ones
+// received after a match against a tracked player
+func (w *Watcher) Check(ctx context.Context, client *steam.Client) ([]Ban, error) {
+	if w == nil {
+		return nil, nil
+	}
+
+	w.mu.Lock()
+	w.forgetExpired()
+	steamIDs := make([]string, 0, len(w.opponents))
+	for steamID := range w.opponents {
+		steamIDs = append(steamIDs, steamID)
+	}
+	w.mu.Unlock()
+
+	if len(steamIDs) == 0 {
+		return nil, nil
+	}
+
+	// Bans of the batches that succeeded are still processed
+	playerBans, err := client.GetPlayerBans(ctx, steamIDs)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	today := startOfDay(w.clock.Now())
+
+	var bans []Ban
+	for _, pb := range playerBans {
+		o, found := w.opponents[pb.SteamID]
+		if !found {
+			continue
+		}
+
+		ban, isNew := newBan(o, pb, today)
+		if !isNew {
+			// Nothing to announce, the current bans are the new reference
+			o.Checked = true
+			o.VACBans = pb.VACBans
+			o.GameBans = pb.GameBans
+			continue
+		}
+
+		ban.SteamID = pb.SteamID
+		bans = append(bans, ban)
+	}
+
+	if saveErr := w.save(); saveErr != nil {
+		err = errors.Join(err, saveErr)
+	}
+
+	return bans, err
+}
+
+// Acknowledge records the ban as announced so Check stops reporting it
+func (w *Watcher) Acknowledge(ban Ban) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	o, found := w.opponents[ban.SteamID]
+	if !found {
+		return nil
+	}
+	o.Checked = true
+	o.VACBans = ban.VACBans
+	o.GameBans = ban.GameBans
+
+	return w.save()
+}
+
+// newBan compares the bans of the opponent with the last known ones. The
+// first time an opponent is checked, its latest ban is new when it was
+// received on or after the day of the first match played against it.
+func newBan(o *opponent, pb steam.PlayerBans, today time.Time) (Ban, bool) {
+	if !pb.HasBans() || len(o.Matches) == 0 {
+		return Ban{}, false
+	}
+
+	bannedAt := today.AddDate(0, 0, -pb.DaysSinceLastBan)
+
+	var newVAC, newGame bool
+	if o.Checked {
+		newVAC = pb.VACBans > o.VACBans
+		newGame = pb.GameBans > o.GameBans
+	} else if !bannedAt.Before(startOfDay(o.Matches[0].FinishedAt)) {
+		// Steam only dates the latest ban, both kinds are reported when the
+		// opponent has both
+		newVAC = pb.VACBans > 0
+		newGame = pb.GameBans > 0
+	}
+	if !newVAC && !newGame {
+		return Ban{}, false
+	}
+
+	var matches []MatchRef
+	for _, m := range o.Matches {
+		if startOfDay(m.FinishedAt).After(bannedAt) {
+			break
+		}
+		matches = append(matches, m)
+	}
+	if len(matches) == 0 {
+		matches = slices.Clone(o.Matches)
+	}
+
+	return Ban{
+		Name:     o.Name,
+		VACBans:  pb.VACBans,
+		GameBans: pb.GameBans,
+		NewVAC:   newVAC,
+		NewGame:  newGame,
+		BannedAt: bannedAt,
+		Matches:  matches,
+	}, true
+}
+
+// forgetExpired drops the opponents not met since the retention
+func (w *Watcher) forgetExpired() {
+	cutoff := w.clock.Now().Add(-w.retention)
+	for steamID, o := range w.opponents {
+		if o.lastMatch().Before(cutoff) {
+			delete(w.opponents, steamID)
+		}
+	}
+}
+
+func (w *Watcher) save() error {
+	if len(w.path) == 0 {
+		return nil
+	}
+
+	return storage.WriteJSON(w.path, watchFile{Version: watchFileVersion, Opponents: w.opponents})
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,60 @@
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time of the crawlers and the session pipeline.
+// The real clock is used in production, the simulated and scaled clocks
+// drive the same code without waiting for hours.
+type Clock interface {
+	Now() time.Time
+	// Sleep pauses for the given duration, or less when the context is done
+	Sleep(ctx context.Context, d time.Duration) error
+	NewTicker(d time.Duration) Ticker
+}
+
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real returns the wall clock
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,40 @@
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Scaled is a clock running faster than the wall clock, starting from now.
+// It is used by the simulate mode to go through hours of sessions in minutes.
+type Scaled struct {
+	start time.Time
+	speed float64
+}
+
+func NewScaled(speed float64) *Scaled {
+	if speed <= 0 {
+		speed = 1
+	}
+
+	return &Scaled{start: time.Now(), speed: speed}
+}
+
+func (s *Scaled) Now() time.Time {
+	elapsed := time.Since(s.start)
+	return s.start.Add(time.Duration(float64(elapsed) * s.speed))
+}
+
+func (s *Scaled) Sleep(ctx context.Context, d time.Duration) error {
+	return Real().Sleep(ctx, s.real(d))
+}
+
+// NewTicker returns a ticker firing at the scaled interval,
+// the tick values are wall clock times
+func (s *Scaled) NewTicker(d time.Duration) Ticker {
+	return Real().NewTicker(s.real(d))
+}
+
+func (s *Scaled) real(d time.Duration) time.Duration {
+	return max(time.Duration(float64(d)/s.speed), time.Millisecond)
+}
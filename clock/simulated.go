@@ -0,0 +1,142 @@
+package clock
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Simulated is a clock that only moves when advanced,
+// sleepers and tickers fire as the simulated time goes by
+type Simulated struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+type waiter struct {
+	deadline time.Time
+	period   time.Duration // zero for a one shot sleep
+	ch       chan time.Time
+}
+
+func NewSimulated(start time.Time) *Simulated {
+	return &Simulated{now: start}
+}
+
+func (s *Simulated) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.now
+}
+
+func (s *Simulated) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	w := s.add(d, 0)
+	select {
+	case <-w.ch:
+		return nil
+	case <-ctx.Done():
+		s.remove(w)
+		return ctx.Err()
+	}
+}
+
+func (s *Simulated) NewTicker(d time.Duration) Ticker {
+	return &simulatedTicker{clock: s, w: s.add(d, d)}
+}
+
+// Advance moves the time forward, firing every sleeper and ticker
+// whose deadline is reached in chronological order
+func (s *Simulated) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := s.now.Add(d)
+	for {
+		i := s.nextWaiter(target)
+		if i < 0 {
+			break
+		}
+
+		w := s.waiters[i]
+		s.now = w.deadline
+
+		// Like time.Ticker, ticks are dropped when the receiver is late
+		select {
+		case w.ch <- s.now:
+		default:
+		}
+
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+		} else {
+			s.waiters = slices.Delete(s.waiters, i, i+1)
+		}
+	}
+	s.now = target
+}
+
+// Waiters returns the number of pending sleepers and tickers,
+// which lets a driver wait for the pipeline to settle before advancing
+func (s *Simulated) Waiters() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.waiters)
+}
+
+// nextWaiter returns the index of the earliest waiter due before target, or -1
+func (s *Simulated) nextWaiter(target time.Time) int {
+	next := -1
+	for i, w := range s.waiters {
+		if w.deadline.After(target) {
+			continue
+		}
+		if next < 0 || w.deadline.Before(s.waiters[next].deadline) {
+			next = i
+		}
+	}
+	return next
+}
+
+func (s *Simulated) add(d, period time.Duration) *waiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := &waiter{
+		deadline: s.now.Add(d),
+		period:   period,
+		ch:       make(chan time.Time, 1),
+	}
+	s.waiters = append(s.waiters, w)
+
+	return w
+}
+
+func (s *Simulated) remove(w *waiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := slices.Index(s.waiters, w); i >= 0 {
+		s.waiters = slices.Delete(s.waiters, i, i+1)
+	}
+}
+
+type simulatedTicker struct {
+	clock *Simulated
+	w     *waiter
+}
+
+func (t *simulatedTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *simulatedTicker) Stop() {
+	t.clock.remove(t.w)
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"time"
 
+	"github.com/mxdc/cs2-discord-bot/steam/steamid"
 	"gopkg.in/yaml.v3"
 )
 
@@ -11,6 +15,9 @@ type Player struct {
 	AccountName string `yaml:"accountName"`
 	SteamID     string `yaml:"steamId"`
 	Track       bool   `yaml:"track"`
+	// Polling bounds of the crawler, defaults are used when empty
+	MinPollInterval time.Duration `yaml:"minPollInterval"`
+	MaxPollInterval time.Duration `yaml:"maxPollInterval"`
 }
 
 func (p *Player) PlayerID() string {
@@ -29,23 +36,135 @@ type AppConfig struct {
 	DiscordHook   string   `yaml:"discord_hook"`
 	Lang          string   `yaml:"lang"`
 	Players       []Player `yaml:"players"`
+	// Requests per minute shared by every call to the Leetify API
+	LeetifyRequestsPerMinute int `yaml:"leetify_requests_per_minute"`
+	// FACEIT matches are fetched from the FACEIT Data API when a key is set
+	FaceitAPIKey string `yaml:"faceit_api_key"`
+	FaceitAPIURL string `yaml:"faceit_api_url"`
 }
 
-func MustLoadConfig(filename string) *AppConfig {
+// TrackedPlayers returns the players a crawler should run for
+func (c *AppConfig) TrackedPlayers() []Player {
+	tracked := []Player{}
+
+	for _, player := range c.Players {
+		if player.Track {
+			tracked = append(tracked, player)
+		}
+	}
+	return tracked
+}
+
+// ResolvePlayers fills the Steam ID of players configured with an account
+// name only. When both are set and disagree, the configured Steam ID is kept
+// and a warning is logged. Players that cannot be resolved are left as is.
+func (c *AppConfig) ResolvePlayers(resolve func(accountName string) (string, error)) {
+	for i := range c.Players {
+		player := &c.Players[i]
+		if len(player.AccountName) == 0 {
+			continue
+		}
+
+		steamID, err := resolve(player.AccountName)
+		if err != nil {
+			if len(player.SteamID) == 0 {
+				log.Printf("Config: Warning: players[%d]: unable to resolve %q, it will not be recognized in matches: %v", i, player.AccountName, err)
+			}
+			continue
+		}
+
+		if len(player.SteamID) == 0 {
+			player.SteamID = steamID
+		} else if player.SteamID != steamID {
+			log.Printf("Config: Warning: players[%d]: accountName %q is the Steam account %s but steamId is %s, keeping %s",
+				i, player.AccountName, steamID, player.SteamID, player.SteamID)
+		}
+	}
+}
+
+// NormalizePlayers rewrites Steam IDs to Steam64 and account names to vanity
+// names, whatever format they were copied in. A vanity URL given as steamId
+// is moved to accountName so it is resolved like one.
+func (c *AppConfig) NormalizePlayers() error {
+	for i := range c.Players {
+		player := &c.Players[i]
+
+		if len(player.AccountName) > 0 {
+			name, err := steamid.ParseVanity(player.AccountName)
+			if err != nil {
+				return fmt.Errorf("players[%d]: accountName: %w", i, err)
+			}
+			player.AccountName = name
+		}
+
+		if len(player.SteamID) == 0 {
+			continue
+		}
+
+		id, err := steamid.Parse(player.SteamID)
+		if err != nil {
+			return fmt.Errorf("players[%d]: steamId: %w", i, err)
+		}
+
+		if id.IsVanity() {
+			if len(player.AccountName) > 0 && player.AccountName != id.Vanity {
+				return fmt.Errorf("players[%d]: steamId is the vanity URL of %q but accountName is %q", i, id.Vanity, player.AccountName)
+			}
+			player.AccountName = id.Vanity
+			player.SteamID = ""
+			continue
+		}
+
+		player.SteamID = id.String()
+	}
+
+	return nil
+}
+
+// Validate checks the configuration can be used by the bot
+func (c *AppConfig) Validate() error {
+	if len(c.Players) == 0 {
+		return errors.New("no players configured")
+	}
+
+	for i, player := range c.Players {
+		if len(player.PlayerID()) == 0 {
+			return fmt.Errorf("players[%d]: accountName or steamId is required", i)
+		}
+	}
+
+	return nil
+}
+
+// LoadConfig reads and validates the configuration file
+func LoadConfig(filename string) (*AppConfig, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		log.Fatalf("Config: Error reading config file: %v", err)
+		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
 	var config AppConfig
 	err = yaml.Unmarshal(data, &config)
 	if err != nil {
-		log.Fatalf("Config: Error parsing config file: %v", err)
+		return nil, fmt.Errorf("error parsing config file: %w", err)
 	}
 
-	if len(config.Players) == 0 {
-		log.Fatal("Config: No players configured")
+	if err := config.NormalizePlayers(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+
+	return &config, nil
+}
+
+func MustLoadConfig(filename string) *AppConfig {
+	config, err := LoadConfig(filename)
+	if err != nil {
+		log.Fatalf("Config: %v", err)
 	}
 
-	return &config
+	return config
 }
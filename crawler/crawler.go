@@ -1,108 +1,222 @@
 package crawler
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"sort"
+	"sync/atomic"
 	"time"
 
+	"github.com/mxdc/cs2-discord-bot/clock"
 	"github.com/mxdc/cs2-discord-bot/config"
-	"github.com/mxdc/cs2-discord-bot/leetify"
+	"github.com/mxdc/cs2-discord-bot/provider"
 	"github.com/mxdc/cs2-discord-bot/session"
 )
 
 type Crawler struct {
-	client    *leetify.LeetifyClient
-	player    config.Player
+	source provider.MatchSource
+	player config.Player
+	// name prefixes the logs of the crawler
+	name      string
 	out       chan<- session.MatchDetected
+	seenGames session.GameStore
+	clock     clock.Clock
 	debugMode bool
+	failures  int
+	health    atomic.Int32
 }
 
 func NewCrawler(
-	client *leetify.LeetifyClient,
+	source provider.MatchSource,
 	player config.Player,
 	out chan<- session.MatchDetected,
+	seenGames session.GameStore,
+	clk clock.Clock,
 	debugMode bool,
 ) *Crawler {
 	return &Crawler{
-		client:    client,
+		source:    source,
 		player:    player,
+		name:      fmt.Sprintf("%s/%s", player.PlayerID(), source.Name()),
 		out:       out,
+		seenGames: seenGames,
+		clock:     clk,
 		debugMode: debugMode,
 	}
 }
 
-func (c *Crawler) StartCrawling() {
-	log.Printf("%s: Crawler started", c.player.PlayerID())
-	before, err := c.client.GetPlayerMatches(c.player)
+// StartCrawling polls the player matches until the context is done
+func (c *Crawler) StartCrawling(ctx context.Context) {
+	log.Printf("%s: Crawler started", c.name)
+	defer log.Printf("%s: Crawler stopped", c.name)
+
+	before, err := c.fetchBaseline(ctx)
 	if err != nil {
-		log.Fatalf("%s: Error: %v", c.player.PlayerID(), err)
+		return
 	}
 	// In debug mode, we want to process the last 5 matches on startup
 	// to test the notifier without having to play new matches
 	if c.debugMode {
-		log.Printf("%s: Debug mode enabled", c.player.PlayerID())
-		if len(before.Games) > 5 {
-			before.Games = before.Games[5:]
+		log.Printf("%s: Debug mode enabled", c.name)
+		if len(before) > 5 {
+			before = before[5:]
 		}
 	}
-	log.Printf("%s: %d previous matches", c.player.PlayerID(), len(before.Games))
+	before = c.baseline(before)
+	log.Printf("%s: %d previous matches", c.name, len(before))
+
+	schedule := newPollSchedule(c.player.MinPollInterval, c.player.MaxPollInterval)
 	if !c.debugMode {
-		time.Sleep(10 * time.Minute)
+		if c.clock.Sleep(ctx, schedule.min) != nil {
+			return
+		}
 	}
 
 	for {
-		after, err := c.client.GetPlayerMatches(c.player)
+		after, err := c.source.RecentMatches(ctx, c.player)
+		if ctx.Err() != nil {
+			return
+		}
+		c.recordResult(err)
 		if err != nil {
-			log.Printf("%s: Error: %v", c.player.PlayerID(), err)
-			time.Sleep(15 * time.Minute)
+			delay := schedule.next(c.clock.Now(), false)
+			log.Printf("%s: Error: %v, retrying in %s", c.name, err, delay)
+			if c.clock.Sleep(ctx, delay) != nil {
+				return
+			}
 			continue
 		}
 
-		if len(after.Games) == 0 {
-			log.Printf("%s: No matches found, retrying in 1 hour", c.player.PlayerID())
-			time.Sleep(1 * time.Hour)
+		if len(after) == 0 {
+			delay := schedule.next(c.clock.Now(), false)
+			log.Printf("%s: No matches found, retrying in %s", c.name, delay)
+			if c.clock.Sleep(ctx, delay) != nil {
+				return
+			}
 			continue
 		}
 
 		// Check for new matches
-		newMatches := findNewMatches(before.Games, after.Games)
+		newMatches := findNewMatches(before, after)
 		for _, match := range newMatches {
-			log.Printf("%s: New match found: %s", c.player.PlayerID(), match.GameId)
-			c.out <- session.MatchDetected{Match: match, Player: c.player, DetectedAt: time.Now()}
+			log.Printf("%s: New match found: %s", c.name, match.GameID)
+			select {
+			case c.out <- session.MatchDetected{Match: match, Player: c.player, DetectedAt: c.clock.Now()}:
+			case <-ctx.Done():
+				return
+			}
 		}
 
 		before = after
 
 		if len(newMatches) > 0 {
-			log.Printf("%s: found %d new", c.player.PlayerID(), len(newMatches))
+			log.Printf("%s: found %d new", c.name, len(newMatches))
 		}
 
-		time.Sleep(1 * time.Hour)
+		delay := schedule.next(c.clock.Now(), len(newMatches) > 0)
+		if c.debugMode {
+			log.Printf("%s: Next poll in %s", c.name, delay)
+		}
+		if c.clock.Sleep(ctx, delay) != nil {
+			return
+		}
 	}
 }
 
+// Health returns the state of the crawler based on its latest requests
+func (c *Crawler) Health() Health {
+	return Health(c.health.Load())
+}
+
+// recordResult updates the health of the crawler after a request
+func (c *Crawler) recordResult(err error) {
+	if err == nil {
+		c.failures = 0
+	} else {
+		c.failures++
+	}
+
+	health := healthFromFailures(c.failures)
+	previous := Health(c.health.Swap(int32(health)))
+	if health != previous {
+		log.Printf("%s: Crawler is now %s (was %s)", c.name, health, previous)
+	}
+}
+
+// fetchBaseline gets the initial matches of the player, retrying with an
+// exponential backoff until it succeeds or the context is done
+func (c *Crawler) fetchBaseline(ctx context.Context) ([]provider.Match, error) {
+	for {
+		matches, err := c.source.RecentMatches(ctx, c.player)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		c.recordResult(err)
+		if err == nil {
+			return matches, nil
+		}
+
+		delay := retryDelay(c.failures)
+		log.Printf("%s: Error: %v, retrying in %s", c.name, err, delay.Round(time.Second))
+		if err := c.clock.Sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// baseline returns the matches considered as already known on startup.
+// When seen games of the player were restored from a previous run, matches
+// played while the bot was offline are left out so they get detected as
+// new, and matches older than the retention are known as they were pruned.
+// Otherwise the first fetch is recorded as seen, which migrates an empty
+// store or a newly added player without announcing the whole history again.
+func (c *Crawler) baseline(games []provider.Match) []provider.Match {
+	if c.seenGames.Restored() && c.seenGames.HasGames(c.player.SteamID) {
+		var known []provider.Match
+		for _, game := range games {
+			if c.seenGames.Expired(game) || !c.seenGames.ShouldNotify(c.player.SteamID, game) {
+				known = append(known, game)
+			}
+		}
+		return known
+	}
+
+	// Games played after the last recorded one may have been played with
+	// other players while the bot was offline, their crawlers announce them
+	var lastSeen time.Time
+	if c.seenGames.Restored() {
+		lastSeen, _ = time.Parse(time.RFC3339, c.seenGames.MostRecentGame().GameFinishedAt)
+	}
+
+	for _, game := range games {
+		if !lastSeen.IsZero() && game.GameFinishedAt.After(lastSeen) {
+			continue
+		}
+		if c.seenGames.ShouldNotify(c.player.SteamID, game) {
+			c.seenGames.AddGame(c.player.SteamID, game)
+		}
+	}
+	return games
+}
+
 // findNewMatches returns matches that are in current but not in previous
-func findNewMatches(previous, current []leetify.LeetifyGameResponse) []leetify.LeetifyGameResponse {
+func findNewMatches(previous, current []provider.Match) []provider.Match {
 	prevSet := make(map[string]bool)
 	for _, match := range previous {
-		prevSet[match.GameId] = true
+		prevSet[match.GameID] = true
 	}
 
-	var newMatches []leetify.LeetifyGameResponse
+	var newMatches []provider.Match
 	for _, match := range current {
-		if !prevSet[match.GameId] {
+		if !prevSet[match.GameID] {
 			newMatches = append(newMatches, match)
 		}
 	}
 
 	// Sort newMatches by GameFinishedAt field, from oldest to newest
 	sort.Slice(newMatches, func(i, j int) bool {
-		currentTimeI, errI := time.Parse(time.RFC3339, newMatches[i].GameFinishedAt)
-		currentTimeJ, errJ := time.Parse(time.RFC3339, newMatches[j].GameFinishedAt)
-		if errI != nil || errJ != nil {
-			return false
-		}
-		return currentTimeI.Before(currentTimeJ)
+		return newMatches[i].GameFinishedAt.Before(newMatches[j].GameFinishedAt)
 	})
 
 	return newMatches
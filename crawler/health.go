@@ -0,0 +1,60 @@
+package crawler
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Health is the state of a crawler as seen from its latest requests
+type Health int32
+
+const (
+	Healthy Health = iota
+	// Degraded crawlers had recent failures but keep retrying
+	Degraded
+	// Failing crawlers have failed too many times in a row
+	Failing
+)
+
+const (
+	failingThreshold = 5
+	retryBaseDelay   = 30 * time.Second
+	retryMaxDelay    = 30 * time.Minute
+)
+
+func (h Health) String() string {
+	switch h {
+	case Healthy:
+		return "healthy"
+	case Degraded:
+		return "degraded"
+	case Failing:
+		return "failing"
+	default:
+		return "unknown"
+	}
+}
+
+func healthFromFailures(failures int) Health {
+	if failures == 0 {
+		return Healthy
+	}
+	if failures < failingThreshold {
+		return Degraded
+	}
+	return Failing
+}
+
+// retryDelay returns an exponential backoff delay for the given number of
+// consecutive failures, with jitter so crawlers don't retry in lockstep
+func retryDelay(failures int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < failures && delay < retryMaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, retryMaxDelay)
+
+	// Full jitter between half and the whole delay
+	half := delay / 2
+	return half + rand.N(half+1)
+}
@@ -0,0 +1,196 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/config"
+	"github.com/mxdc/cs2-discord-bot/discord"
+	"github.com/mxdc/cs2-discord-bot/locales"
+	"github.com/mxdc/cs2-discord-bot/provider"
+	"github.com/mxdc/cs2-discord-bot/session"
+)
+
+// fakeSource lists the matches played so far, most recent first
+type fakeSource struct {
+	mu      sync.Mutex
+	matches []provider.Match
+}
+
+func (s *fakeSource) Name() string {
+	return "fake"
+}
+
+func (s *fakeSource) RecentMatches(ctx context.Context, player config.Player) ([]provider.Match, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.matches), nil
+}
+
+func (s *fakeSource) MatchDetails(ctx context.Context, match provider.Match) (*provider.MatchDetails, error) {
+	return &provider.MatchDetails{
+		GameID:  match.GameID,
+		MapName: match.MapName,
+		Players: []provider.PlayerStats{{SteamID: testPlayer.SteamID, Name: "player", Kills: 20, Deaths: 15}},
+	}, nil
+}
+
+func (s *fakeSource) play(gameID string, finishedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	match := provider.Match{
+		Provider:          "fake",
+		GameID:            gameID,
+		GameFinishedAt:    finishedAt,
+		GameMode:          "Competitive",
+		MapName:           "de_dust2",
+		MatchResult:       "win",
+		Scores:            []int{13, 8},
+		OwnTeamSteam64Ids: []string{testPlayer.SteamID},
+	}
+	s.matches = append([]provider.Match{match}, s.matches...)
+}
+
+var testPlayer = config.Player{AccountName: "player", SteamID: "76561198000000001", Track: true}
+
+// pipelineWaiters are the clock waiters of an idle pipeline:
+// the crawler sleeping until its next poll and the manager ticker
+const pipelineWaiters = 2
+
+// settle waits until every goroutine of the pipeline is blocked on the clock,
+// so the next Advance is seen by all of them
+func settle(t *testing.T, clk *clock.Simulated) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for clk.Waiters() != pipelineWaiters {
+		if time.Now().After(deadline) {
+			t.Fatalf("pipeline not settled, %d clock waiters", clk.Waiters())
+		}
+		runtime.Gosched()
+	}
+}
+
+// step moves the clock forward 5 minutes at a time, the interval of both
+// the crawler polls and the manager inactivity checks
+func step(t *testing.T, clk *clock.Simulated, d time.Duration) {
+	t.Helper()
+
+	for elapsed := time.Duration(0); elapsed < d; elapsed += 5 * time.Minute {
+		clk.Advance(5 * time.Minute)
+		settle(t, clk)
+	}
+}
+
+type post struct {
+	message discord.WebhookMessage
+	at      time.Time
+}
+
+func TestPipelineNotifiesSessionOnceAfterInactivityTimeout(t *testing.T) {
+	start := time.Date(2025, 1, 31, 20, 0, 0, 0, time.UTC)
+	clk := clock.NewSimulated(start)
+
+	posts := make(chan post, 4)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message discord.WebhookMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("invalid webhook message: %v", err)
+		}
+		posts <- post{message: message, at: clk.Now()}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer webhook.Close()
+
+	source := &fakeSource{}
+	settings := session.NewLiveSettings(&config.AppConfig{
+		DiscordHook: webhook.URL,
+		Players:     []config.Player{testPlayer},
+	}, locales.Translations{})
+
+	detected := make(chan session.MatchDetected)
+	completed := make(chan session.GameSession, 4)
+	seenGames := session.NewSeenGames()
+	crawler := NewCrawler(source, testPlayer, detected, seenGames, clk, false)
+	manager := session.NewSessionManager(detected, completed, seenGames, nil, clk, false)
+	notifier := session.NewSessionNotifier(settings, provider.NewRegistry(source), nil, nil, nil, nil, clk, completed, false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var crawling, handling sync.WaitGroup
+	crawling.Add(1)
+	go func() {
+		defer crawling.Done()
+		crawler.StartCrawling(ctx)
+	}()
+	handling.Add(2)
+	go func() {
+		defer handling.Done()
+		manager.HandleIncomingMatches(context.Background())
+	}()
+	go func() {
+		defer handling.Done()
+		notifier.HandleSession(context.Background())
+	}()
+
+	// The baseline is empty, both matches are played after the startup
+	settle(t, clk)
+	source.play("game-1", clk.Now())
+	step(t, clk, 30*time.Minute)
+	source.play("game-2", clk.Now())
+	step(t, clk, 5*time.Minute)
+	lastDetection := clk.Now()
+
+	// 3h25 after the last detection, the session is still open
+	step(t, clk, 3*time.Hour+25*time.Minute)
+	select {
+	case p := <-posts:
+		t.Fatalf("session notified before the timeout: %q", p.message.Content)
+	default:
+	}
+
+	// past the 3h30 timeout, both matches are notified together
+	step(t, clk, 10*time.Minute)
+	select {
+	case p := <-posts:
+		if p.at.Sub(lastDetection) <= 3*time.Hour+30*time.Minute {
+			t.Errorf("session notified %s after the last detection", p.at.Sub(lastDetection))
+		}
+		if len(p.message.Embeds) != 1 || len(p.message.Embeds[0].Fields) == 0 {
+			t.Fatalf("got %d embeds, want a session summary", len(p.message.Embeds))
+		}
+		summary := p.message.Embeds[0].Fields[0].Value
+		for _, gameID := range []string{"game-1", "game-2"} {
+			if !strings.Contains(summary, gameID) {
+				t.Errorf("match %s missing from the session summary %q", gameID, summary)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("session not notified after the inactivity timeout")
+	}
+
+	// nothing else is notified, even after hours of inactivity
+	step(t, clk, 6*time.Hour)
+	cancel()
+	crawling.Wait()
+	close(detected)
+	handling.Wait()
+
+	select {
+	case p := <-posts:
+		t.Fatalf("unexpected second notification: %q", p.message.Content)
+	default:
+	}
+}
@@ -0,0 +1,144 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/config"
+	"github.com/mxdc/cs2-discord-bot/provider"
+	"github.com/mxdc/cs2-discord-bot/session"
+)
+
+// Pool runs one crawler per tracked player and match source, and keeps
+// the set of crawlers in sync with the configuration
+type Pool struct {
+	sources   []provider.MatchSource
+	out       chan<- session.MatchDetected
+	seenGames session.GameStore
+	clock     clock.Clock
+	debugMode bool
+
+	mu      sync.Mutex
+	running map[string]*runningCrawler
+	wg      sync.WaitGroup
+}
+
+type runningCrawler struct {
+	crawler *Crawler
+	cancel  context.CancelFunc
+}
+
+func NewPool(
+	sources []provider.MatchSource,
+	out chan<- session.MatchDetected,
+	seenGames session.GameStore,
+	clk clock.Clock,
+	debugMode bool,
+) *Pool {
+	return &Pool{
+		sources:   sources,
+		out:       out,
+		seenGames: seenGames,
+		clock:     clk,
+		debugMode: debugMode,
+		running:   make(map[string]*runningCrawler),
+	}
+}
+
+// Sync starts crawlers for new players and stops the ones of removed players.
+// Crawlers of players whose settings changed are restarted.
+func (p *Pool) Sync(ctx context.Context, players []config.Player) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	wanted := make(map[string]*Crawler, len(players)*len(p.sources))
+	for _, player := range players {
+		for _, source := range p.sources {
+			crawler := NewCrawler(source, player, p.out, p.seenGames, p.clock, p.debugMode)
+			wanted[crawler.name] = crawler
+		}
+	}
+
+	for name, running := range p.running {
+		crawler, found := wanted[name]
+		if found && crawler.player == running.crawler.player {
+			continue
+		}
+
+		log.Printf("%s: Stopping crawler", name)
+		running.cancel()
+		delete(p.running, name)
+	}
+
+	for name, crawler := range wanted {
+		if _, found := p.running[name]; found {
+			continue
+		}
+
+		crawlerCtx, cancel := context.WithCancel(ctx)
+		p.running[name] = &runningCrawler{crawler: crawler, cancel: cancel}
+
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			crawler.StartCrawling(crawlerCtx)
+		}()
+	}
+
+	log.Printf("CS2: Tracking matches for %d player(s) from %d source(s)", len(players), len(p.sources))
+}
+
+// Health returns the health of every running crawler by name
+func (p *Pool) Health() map[string]Health {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	health := make(map[string]Health, len(p.running))
+	for name, running := range p.running {
+		health[name] = running.crawler.Health()
+	}
+	return health
+}
+
+// ReportHealth logs a summary of the crawlers health every interval until
+// the context is done, naming the crawlers that are not healthy
+func (p *Pool) ReportHealth(ctx context.Context, interval time.Duration) {
+	ticker := p.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+
+		counts := map[Health]int{}
+		var unhealthy []string
+		for name, health := range p.Health() {
+			counts[health]++
+			if health != Healthy {
+				unhealthy = append(unhealthy, fmt.Sprintf("%s (%s)", name, health))
+			}
+		}
+		slices.Sort(unhealthy)
+
+		summary := fmt.Sprintf("%d healthy, %d degraded, %d failing", counts[Healthy], counts[Degraded], counts[Failing])
+		if len(unhealthy) == 0 {
+			log.Printf("CS2: Crawlers: %s", summary)
+			continue
+		}
+		log.Printf("CS2: Crawlers: %s: %s", summary, strings.Join(unhealthy, ", "))
+	}
+}
+
+// Wait blocks until every crawler has stopped
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
@@ -0,0 +1,58 @@
+package crawler
+
+import (
+	"time"
+)
+
+const (
+	defaultMinPollInterval = 5 * time.Minute
+	defaultMaxPollInterval = 1 * time.Hour
+	// activeWindow is how long a player is considered active after a new match,
+	// roughly the duration of the next match
+	activeWindow  = 1 * time.Hour
+	backoffFactor = 2
+)
+
+// pollSchedule computes the delay between two polls of a player.
+// It polls at the minimum interval while the player is active and backs off
+// gradually, up to the maximum interval, once they stop playing.
+type pollSchedule struct {
+	min          time.Duration
+	max          time.Duration
+	current      time.Duration
+	lastActiveAt time.Time
+}
+
+func newPollSchedule(min, max time.Duration) *pollSchedule {
+	if min <= 0 {
+		min = defaultMinPollInterval
+	}
+	if max <= 0 {
+		max = defaultMaxPollInterval
+	}
+	if max < min {
+		max = min
+	}
+
+	return &pollSchedule{
+		min:     min,
+		max:     max,
+		current: min,
+	}
+}
+
+// next returns the delay before the next poll,
+// foundNew tells whether the last poll returned new matches
+func (s *pollSchedule) next(now time.Time, foundNew bool) time.Duration {
+	if foundNew {
+		s.lastActiveAt = now
+	}
+
+	if now.Sub(s.lastActiveAt) < activeWindow {
+		s.current = s.min
+		return s.current
+	}
+
+	s.current = min(s.current*backoffFactor, s.max)
+	return s.current
+}
@@ -0,0 +1,113 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mxdc/cs2-discord-bot/banwatch"
+	"github.com/mxdc/cs2-discord-bot/locales"
+)
+
+type BanAlertBuilder struct {
+	ban          banwatch.Ban
+	translations locales.Translations
+}
+
+func NewBanAlertBuilder(ban banwatch.Ban, translations locales.Translations) *BanAlertBuilder {
+	return &BanAlertBuilder{
+		ban:          ban,
+		translations: translations,
+	}
+}
+
+func (b *BanAlertBuilder) BuildMessage() WebhookMessage {
+	name := b.ban.Name
+	if len(name) == 0 {
+		name = b.ban.SteamID
+	}
+	player := fmt.Sprintf("[%s](https://steamcommunity.com/profiles/%s)", name, b.ban.SteamID)
+
+	var content string
+	if b.ban.NewVAC {
+		content = fmt.Sprintf(b.translations.BanVac, player)
+	} else {
+		content = fmt.Sprintf(b.translations.BanGame, player)
+	}
+
+	var fields []EmbedField
+	if len(b.ban.Matches) > 0 {
+		fields = append(fields, EmbedField{
+			Name:   "",
+			Value:  formatBannedMatches(b.ban.Matches),
+			Inline: false,
+		})
+	}
+
+	return WebhookMessage{
+		Content: content,
+		TTS:     false,
+		Embeds: []Embed{{
+			Title:  "",
+			Color:  ColorRed,
+			Fields: fields,
+		}},
+		Username: b.translations.BotUsername,
+	}
+}
+
+// maxFieldValueLength is the maximum length of an embed field value allowed by Discord
+const maxFieldValueLength = 1024
+
+// formatBannedMatches lists the matches played against a banned player, one
+// per line. Matches that do not fit in a field are counted in a last line.
+func formatBannedMatches(matches []banwatch.MatchRef) string {
+	var lines []string
+	length := 0
+	for i, match := range matches {
+		line := formatBannedMatchLine(match)
+
+		// Keep room for the line counting the remaining matches
+		more := ""
+		if remaining := len(matches) - i - 1; remaining > 0 {
+			more = fmt.Sprintf("\n+%d more", remaining)
+		}
+		if length+len(line)+len(more)+1 > maxFieldValueLength {
+			lines = append(lines, fmt.Sprintf("+%d more", len(matches)-i))
+			break
+		}
+
+		lines = append(lines, line)
+		length += len(line) + 1
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// formatBannedMatchLine formats a match played against a banned player,
+// linked to the match page when the source has one
+func formatBannedMatchLine(match banwatch.MatchRef) string {
+	result := fmt.Sprintf("%s · %d-%d · %s", match.GameMode, match.OwnScore, match.EnemyScore, match.MapName)
+	date := match.FinishedAt.Format("2006-01-02")
+
+	if len(match.Link) == 0 {
+		return fmt.Sprintf("🚨 **%s** · %s", result, date)
+	}
+
+	return fmt.Sprintf("🚨 [**%s**](%s) · %s", result, match.Link, date)
+}
+
+// SendBanAlert announces a ban received by an opponent
+func (c *WebhookClient) SendBanAlert(ctx context.Context, ban banwatch.Ban) error {
+	message := NewBanAlertBuilder(ban, c.translations).BuildMessage()
+
+	log.Printf("Discord: Sending ban alert for %s...", ban.SteamID)
+
+	if err := c.sendWebhook(ctx, message); err != nil {
+		return err
+	}
+
+	log.Println("Discord: Ban alert sent successfully")
+	return nil
+}
@@ -122,6 +122,114 @@ func (f *EmbedFieldFormatter) addMatchLinkField(match parser.MatchWithDetails) {
 	f.fields = append(f.fields, field)
 }
 
+func (f *EmbedFieldFormatter) addFaceitEloField(match parser.MatchWithDetails) {
+	var lines []string
+	for _, p := range match.OwnTeam.KnownPlayers {
+		oldElo, newElo, level := p.GetRecentFaceitElo()
+		if newElo == 0 {
+			continue
+		}
+
+		playerLink := p.FormatPlayerLink(false, false)
+		lines = append(lines, fmt.Sprintf(
+			"**%s** · **%d** ELO (%+d) · level **%d**",
+			playerLink, newElo, newElo-oldElo, level,
+		))
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	field := EmbedField{
+		Name:   "",
+		Value:  strings.Join(lines, "\n"),
+		Inline: false,
+	}
+	f.fields = append(f.fields, field)
+}
+
+// addPlayerStatsField lists the detailed stats of the tracked players, one per line
+func (f *EmbedFieldFormatter) addPlayerStatsField(match parser.MatchWithDetails) {
+	var lines []string
+	for _, p := range match.OwnTeam.KnownPlayers {
+		// Sources without detailed stats leave the rounds unknown
+		if p.RoundsPlayed == 0 {
+			continue
+		}
+
+		stats := []string{
+			fmt.Sprintf("**%d**/**%d**/**%d**", p.Kills, p.Deaths, p.Assists),
+			fmt.Sprintf("ADR **%.0f**", p.ADR()),
+			fmt.Sprintf("HS **%.0f%%**", p.HeadshotPercentage()),
+		}
+		if multiKills := p.MultiKills(); multiKills > 0 {
+			stats = append(stats, fmt.Sprintf("**%d** multi-kills", multiKills))
+		}
+		if p.UtilityDamage > 0 {
+			stats = append(stats, fmt.Sprintf("utility **%d**", p.UtilityDamage))
+		}
+		if p.HltvRating > 0 {
+			stats = append(stats, fmt.Sprintf("rating **%.2f**", p.HltvRating))
+		}
+
+		playerLink := p.FormatPlayerLink(false, false)
+		lines = append(lines, fmt.Sprintf("**%s** · %s", playerLink, strings.Join(stats, " · ")))
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	field := EmbedField{
+		Name:   "",
+		Value:  strings.Join(lines, "\n"),
+		Inline: false,
+	}
+	f.fields = append(f.fields, field)
+}
+
+func (f *EmbedFieldFormatter) addReplayLinkField(match parser.MatchWithDetails) {
+	replayLink := match.GetReplayLink()
+	if len(replayLink) == 0 {
+		return
+	}
+
+	field := EmbedField{
+		Name:   "",
+		Value:  fmt.Sprintf("▸ [Watch the replay in CS2](%s)", replayLink),
+		Inline: false,
+	}
+
+	f.fields = append(f.fields, field)
+}
+
+// addSuspectsField lists the opponents flagged as suspicious, one per line
+func (f *EmbedFieldFormatter) addSuspectsField(match parser.MatchWithDetails) {
+	if len(match.Suspects) == 0 {
+		return
+	}
+
+	lines := make([]string, len(match.Suspects))
+	for i, suspect := range match.Suspects {
+		name := suspect.Name
+		if len(name) == 0 {
+			name = suspect.SteamID
+		}
+		lines[i] = fmt.Sprintf("🕵️ [%s](https://steamcommunity.com/profiles/%s) · K/D %.2f · %s",
+			name, suspect.SteamID, suspect.KdRatio, strings.Join(suspect.Reasons, ", "))
+	}
+
+	headerStr := "*Suspicious opponents*"
+	field := EmbedField{
+		Name:   "",
+		Value:  fmt.Sprintf("%s\n%s", headerStr, strings.Join(lines, "\n")),
+		Inline: false,
+	}
+
+	f.fields = append(f.fields, field)
+}
+
 func (f *EmbedFieldFormatter) addSessionMatchesField(matches []parser.MatchWithDetails) {
 	if len(matches) == 0 {
 		return
@@ -148,8 +256,8 @@ func (f *EmbedFieldFormatter) addSessionTeammatesField(session parser.SessionWit
 	best := session.BestKillDeathTeammate()
 	worst := session.WorstKillDeathTeammate()
 
-	bestPlayerLink := best.FormatPlayerLink(false, false)
-	worstPlayerLink := worst.FormatPlayerLink(false, false)
+	bestPlayerLink := best.FormatPlayerLink(true, false)
+	worstPlayerLink := worst.FormatPlayerLink(true, false)
 
 	bestTeammateKey := ":star: *Best Buddy*"
 	bestTeammateValue := fmt.Sprintf("**%s** · **%d**K/**%d**D", bestPlayerLink, best.Kills, best.Deaths)
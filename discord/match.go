@@ -27,7 +27,7 @@ func NewMatchResultBuilder(
 
 func (b *MatchResultBuilder) BuildMessage() WebhookMessage {
 	content := formatMatchHeader(b.match, b.translations, b.withRank)
-	embed := createMatchEmbed(b.match)
+	embed := createMatchEmbed(b.match, b.withRank)
 
 	return WebhookMessage{
 		Content:  content,
@@ -52,7 +52,7 @@ func formatMatchHeader(match parser.MatchWithDetails, translations locales.Trans
 	return formatMatchHeaderForMultiplePlayers(translations, match, header)
 }
 
-func createMatchEmbed(match parser.MatchWithDetails) Embed {
+func createMatchEmbed(match parser.MatchWithDetails, withRank bool) Embed {
 	var color int
 
 	if match.Winner == 1 {
@@ -65,6 +65,12 @@ func createMatchEmbed(match parser.MatchWithDetails) Embed {
 
 	fieldsFormatter := NewEmbedFieldFormatter()
 	fieldsFormatter.addMatchOneLinerField(match)
+	if withRank && match.IsFaceitMode() {
+		fieldsFormatter.addFaceitEloField(match)
+	}
+	fieldsFormatter.addPlayerStatsField(match)
+	fieldsFormatter.addReplayLinkField(match)
+	fieldsFormatter.addSuspectsField(match)
 	// fieldsFormatter.addGameModeField(match.GameMode)
 	// fieldsFormatter.addScoreField(match)
 	// fieldsFormatter.addMapNameField(match.MapName)
@@ -91,6 +97,12 @@ func formatMatchHeaderForSinglePlayer(
 ) string {
 	t := translations
 
+	if withRank && match.IsFaceitMode() {
+		if header, ok := formatMatchHeaderWithElo(t, match, playerNameHeader, knownPlayer); ok {
+			return header
+		}
+	}
+
 	_, newRank := knownPlayer.GetRecentPremierRank()
 	displayRank := newRank > 0 && withRank && match.IsPremierMode()
 
@@ -110,6 +122,28 @@ func formatMatchHeaderForSinglePlayer(
 	}
 }
 
+// formatMatchHeaderWithElo announces the ELO of the player after a FACEIT match
+func formatMatchHeaderWithElo(
+	t locales.Translations,
+	match parser.MatchWithDetails,
+	playerNameHeader string,
+	knownPlayer parser.Player,
+) (string, bool) {
+	oldElo, newElo, level := knownPlayer.GetRecentFaceitElo()
+	if newElo == 0 {
+		return "", false
+	}
+
+	switch match.Winner {
+	case 1:
+		return fmt.Sprintf(t.WinSingleElo, playerNameHeader, newElo, newElo-oldElo, level), true
+	case 2:
+		return fmt.Sprintf(t.LossSingleElo, playerNameHeader, newElo, newElo-oldElo, level), true
+	default:
+		return "", false
+	}
+}
+
 func formatMatchHeaderForMultiplePlayers(
 	translations locales.Translations,
 	match parser.MatchWithDetails,
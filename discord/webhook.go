@@ -2,10 +2,12 @@ package discord
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/mxdc/cs2-discord-bot/locales"
@@ -66,52 +68,120 @@ func NewWebhookClient(
 	}
 }
 
-func (c *WebhookClient) SendMatchResult(match parser.MatchWithDetails) {
+func (c *WebhookClient) SendMatchResult(ctx context.Context, match parser.MatchWithDetails) error {
+	message := c.buildMatchMessage(ctx, match)
+
+	log.Println("Discord: Sending Discord notification...")
+
+	if err := c.sendWebhook(ctx, message); err != nil {
+		return err
+	}
+
+	log.Println("Discord: Discord notification sent successfully")
+	return nil
+}
+
+// PostMatchResult sends the match result and returns the ID of the Discord
+// message, so it can be edited once more details are known
+func (c *WebhookClient) PostMatchResult(ctx context.Context, match parser.MatchWithDetails) (string, error) {
+	message := c.buildMatchMessage(ctx, match)
+
+	log.Println("Discord: Sending provisional Discord notification...")
+
+	u, err := url.Parse(c.webhookURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse webhook URL: %w", err)
+	}
+	// wait=true makes Discord return the created message
+	query := u.Query()
+	query.Set("wait", "true")
+	u.RawQuery = query.Encode()
+
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := c.doWebhook(ctx, http.MethodPost, u.String(), message, &created); err != nil {
+		return "", err
+	}
+
+	log.Printf("Discord: Provisional Discord notification sent (message %s)", created.ID)
+	return created.ID, nil
+}
+
+// webhookEdit is the part of a message replaced by EditMatchResult
+type webhookEdit struct {
+	Embeds []Embed `json:"embeds"`
+}
+
+// EditMatchResult replaces the embed of a message sent by PostMatchResult.
+// The content is left as posted so a generated title is not replaced.
+func (c *WebhookClient) EditMatchResult(ctx context.Context, messageID string, match parser.MatchWithDetails) error {
+	message := webhookEdit{
+		Embeds: NewMatchResultBuilder(match, c.translations, c.withRank).BuildMessage().Embeds,
+	}
+
+	u, err := url.Parse(c.webhookURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse webhook URL: %w", err)
+	}
+	u = u.JoinPath("messages", messageID)
+
+	log.Printf("Discord: Editing Discord message %s...", messageID)
+
+	if err := c.doWebhook(ctx, http.MethodPatch, u.String(), message, nil); err != nil {
+		return err
+	}
+
+	log.Printf("Discord: Discord message %s edited successfully", messageID)
+	return nil
+}
+
+func (c *WebhookClient) buildMatchMessage(ctx context.Context, match parser.MatchWithDetails) WebhookMessage {
 	message := NewMatchResultBuilder(match, c.translations, c.withRank).BuildMessage()
 	if c.mistralClient != nil {
-		result := c.mistralClient.GetGeneratedTitles(message.Content)
+		result := c.mistralClient.GetGeneratedTitles(ctx, message.Content)
 		message.Content = result
 	}
 
-	log.Println("Discord: Sending Discord notification...")
-
-	if err := c.sendWebhook(message); err != nil {
-		log.Printf("Discord: Error sending Discord webhook: %v", err)
-	} else {
-		log.Println("Discord: Discord notification sent successfully")
-	}
+	return message
 }
 
-func (c *WebhookClient) SendSessionResult(session parser.SessionWithDetails) {
+func (c *WebhookClient) SendSessionResult(ctx context.Context, session parser.SessionWithDetails) error {
 	if len(session.Matches) == 1 {
-		c.SendMatchResult(session.Matches[0])
-		return
+		return c.SendMatchResult(ctx, session.Matches[0])
 	}
 
 	withRank := c.withRank && session.IsFresh
 	sessionResultBuiler := NewSessionResultBuilder(session, c.translations, withRank)
 	message := sessionResultBuiler.BuildMessage()
 	if c.mistralClient != nil {
-		result := c.mistralClient.GetGeneratedTitles(message.Content)
+		result := c.mistralClient.GetGeneratedTitles(ctx, message.Content)
 		message.Content = result
 	}
 
 	log.Println("Discord: Sending Discord notification...")
 
-	if err := c.sendWebhook(message); err != nil {
-		log.Printf("Discord: Error sending Discord webhook: %v", err)
-	} else {
-		log.Println("Discord: Discord notification sent successfully")
+	if err := c.sendWebhook(ctx, message); err != nil {
+		return err
 	}
+
+	log.Println("Discord: Discord notification sent successfully")
+	return nil
+}
+
+func (c *WebhookClient) sendWebhook(ctx context.Context, message WebhookMessage) error {
+	return c.doWebhook(ctx, http.MethodPost, c.webhookURL, message, nil)
 }
 
-func (c *WebhookClient) sendWebhook(message WebhookMessage) error {
+// doWebhook sends the message to the webhook URL and decodes the response
+// into v when v is not nil
+func (c *WebhookClient) doWebhook(ctx context.Context, method, webhookURL string, message any, v any) error {
 	jsonData, err := json.Marshal(message)
 	if err != nil {
 		return fmt.Errorf("failed to marshal webhook message: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", c.webhookURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, method, webhookURL, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to create webhook request: %w", err)
 	}
@@ -128,5 +198,11 @@ func (c *WebhookClient) sendWebhook(message WebhookMessage) error {
 		return fmt.Errorf("webhook request failed with status: %d %s", resp.StatusCode, resp.Status)
 	}
 
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return fmt.Errorf("failed to decode webhook response: %w", err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,202 @@
+package faceit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const DefaultAPIURL = "https://open.faceit.com/data/v4"
+
+// ErrNotFound is returned for unknown players and matches
+var ErrNotFound = errors.New("faceit: not found")
+
+// FaceitClient calls the FACEIT Data API
+type FaceitClient struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+}
+
+func NewFaceitClient(baseURL, apiKey string) *FaceitClient {
+	if len(baseURL) == 0 {
+		baseURL = DefaultAPIURL
+	}
+
+	return &FaceitClient{
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		baseURL: baseURL,
+		apiKey:  apiKey,
+	}
+}
+
+type PlayerGame struct {
+	FaceitElo    int    `json:"faceit_elo"`
+	SkillLevel   int    `json:"skill_level"`
+	GamePlayerID string `json:"game_player_id"`
+}
+
+type PlayerResponse struct {
+	PlayerID string                `json:"player_id"`
+	Nickname string                `json:"nickname"`
+	Country  string                `json:"country"`
+	Games    map[string]PlayerGame `json:"games"`
+}
+
+type HistoryPlayer struct {
+	PlayerID     string `json:"player_id"`
+	Nickname     string `json:"nickname"`
+	SkillLevel   int    `json:"skill_level"`
+	GamePlayerID string `json:"game_player_id"`
+}
+
+type HistoryTeam struct {
+	TeamID   string          `json:"team_id"`
+	Nickname string          `json:"nickname"`
+	Players  []HistoryPlayer `json:"players"`
+}
+
+type HistoryMatch struct {
+	MatchID    string                 `json:"match_id"`
+	GameMode   string                 `json:"game_mode"`
+	MatchType  string                 `json:"match_type"`
+	Status     string                 `json:"status"`
+	StartedAt  int64                  `json:"started_at"`
+	FinishedAt int64                  `json:"finished_at"`
+	Teams      map[string]HistoryTeam `json:"teams"`
+	Results    struct {
+		Winner string `json:"winner"`
+	} `json:"results"`
+	FaceitURL string `json:"faceit_url"`
+}
+
+type HistoryResponse struct {
+	Items []HistoryMatch `json:"items"`
+}
+
+type RosterPlayer struct {
+	PlayerID       string `json:"player_id"`
+	Nickname       string `json:"nickname"`
+	GamePlayerID   string `json:"game_player_id"`
+	GameSkillLevel int    `json:"game_skill_level"`
+}
+
+type MatchResponse struct {
+	MatchID string `json:"match_id"`
+	Status  string `json:"status"`
+	Teams   map[string]struct {
+		FactionID string         `json:"faction_id"`
+		Name      string         `json:"name"`
+		Roster    []RosterPlayer `json:"roster"`
+	} `json:"teams"`
+	Voting struct {
+		Map struct {
+			Pick []string `json:"pick"`
+		} `json:"map"`
+	} `json:"voting"`
+	FaceitURL string `json:"faceit_url"`
+}
+
+// MatchStatsResponse holds the scoreboard of a match. FACEIT returns every
+// stat as a string, keyed by its display name such as "Kills" or "K/D Ratio".
+type MatchStatsResponse struct {
+	Rounds []struct {
+		RoundStats map[string]string `json:"round_stats"`
+		Teams      []struct {
+			TeamID    string            `json:"team_id"`
+			TeamStats map[string]string `json:"team_stats"`
+			Players   []struct {
+				PlayerID    string            `json:"player_id"`
+				Nickname    string            `json:"nickname"`
+				PlayerStats map[string]string `json:"player_stats"`
+			} `json:"players"`
+		} `json:"teams"`
+	} `json:"rounds"`
+}
+
+// GetPlayerBySteamID looks up the FACEIT profile linked to a Steam account
+func (c *FaceitClient) GetPlayerBySteamID(ctx context.Context, steamID string) (*PlayerResponse, error) {
+	query := url.Values{"game": {"cs2"}, "game_player_id": {steamID}}
+
+	var player PlayerResponse
+	if err := c.getJSON(ctx, "/players", query, &player); err != nil {
+		return nil, fmt.Errorf("player lookup failed: %w", err)
+	}
+
+	return &player, nil
+}
+
+func (c *FaceitClient) GetPlayerHistory(ctx context.Context, playerID string, limit int) (*HistoryResponse, error) {
+	log.Printf("Faceit: Fetching matches of player %s", playerID)
+
+	query := url.Values{"game": {"cs2"}, "offset": {"0"}, "limit": {strconv.Itoa(limit)}}
+
+	var history HistoryResponse
+	if err := c.getJSON(ctx, "/players/"+playerID+"/history", query, &history); err != nil {
+		return nil, fmt.Errorf("match history request failed: %w", err)
+	}
+
+	return &history, nil
+}
+
+func (c *FaceitClient) GetMatch(ctx context.Context, matchID string) (*MatchResponse, error) {
+	var match MatchResponse
+	if err := c.getJSON(ctx, "/matches/"+matchID, nil, &match); err != nil {
+		return nil, fmt.Errorf("match request failed: %w", err)
+	}
+
+	return &match, nil
+}
+
+func (c *FaceitClient) GetMatchStats(ctx context.Context, matchID string) (*MatchStatsResponse, error) {
+	var stats MatchStatsResponse
+	if err := c.getJSON(ctx, "/matches/"+matchID+"/stats", nil, &stats); err != nil {
+		return nil, fmt.Errorf("match stats request failed: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// getJSON sends an authenticated GET request and decodes the response into v
+func (c *FaceitClient) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	u = u.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
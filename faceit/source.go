@@ -0,0 +1,358 @@
+package faceit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/config"
+	"github.com/mxdc/cs2-discord-bot/provider"
+)
+
+const SourceName = "faceit"
+
+const (
+	// historyLimit is the number of recent matches fetched on each poll
+	historyLimit = 20
+	// unlinkedTTL is how long a player without a FACEIT CS2 profile is not
+	// looked up again
+	unlinkedTTL = 6 * time.Hour
+)
+
+// eloChange is the ELO of a player before and after a match
+type eloChange struct {
+	Elo    int
+	OldElo int
+	Level  int
+}
+
+// playerState is what the source remembers of a tracked player between polls
+type playerState struct {
+	elo     int
+	level   int
+	matches map[string]bool
+	changes map[string]eloChange
+}
+
+// Source exposes the FACEIT Data API as a provider.MatchSource.
+// The API does not return the ELO won or lost in a match, so the source
+// compares the ELO of the player between two polls and attributes the
+// difference to the most recent new match. ELO deltas are kept in memory
+// and are unknown for matches played while the bot was stopped.
+type Source struct {
+	client *FaceitClient
+	clock  clock.Clock
+
+	mu      sync.Mutex
+	players map[string]*playerState
+	// unlinked remembers when players were found without a FACEIT CS2 profile
+	unlinked map[string]time.Time
+}
+
+func NewSource(client *FaceitClient, clk clock.Clock) *Source {
+	return &Source{
+		client:   client,
+		clock:    clk,
+		players:  map[string]*playerState{},
+		unlinked: map[string]time.Time{},
+	}
+}
+
+func (s *Source) Name() string {
+	return SourceName
+}
+
+func (s *Source) RecentMatches(ctx context.Context, player config.Player) ([]provider.Match, error) {
+	if s.isUnlinked(player.SteamID) {
+		return nil, nil
+	}
+
+	profile, err := s.client.GetPlayerBySteamID(ctx, player.SteamID)
+	if errors.Is(err, ErrNotFound) {
+		log.Printf("Faceit: %s: No FACEIT account linked to this Steam ID, checking again in %s", player.PlayerID(), unlinkedTTL)
+		s.markUnlinked(player.SteamID)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	game, found := profile.Games["cs2"]
+	if !found {
+		log.Printf("Faceit: %s: No CS2 profile on FACEIT, checking again in %s", player.PlayerID(), unlinkedTTL)
+		s.markUnlinked(player.SteamID)
+		return nil, nil
+	}
+
+	history, err := s.client.GetPlayerHistory(ctx, profile.PlayerID, historyLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []provider.Match
+	for _, item := range history.Items {
+		if item.Status != "finished" {
+			continue
+		}
+		if match, ok := toMatch(item, player.SteamID); ok {
+			matches = append(matches, match)
+		}
+	}
+
+	s.recordElo(player.SteamID, game, matches)
+
+	return matches, nil
+}
+
+// isUnlinked reports whether the player was recently found without a FACEIT CS2 profile
+func (s *Source) isUnlinked(steamID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkedAt, found := s.unlinked[steamID]
+	if !found {
+		return false
+	}
+	if s.clock.Now().Sub(checkedAt) >= unlinkedTTL {
+		delete(s.unlinked, steamID)
+		return false
+	}
+
+	return true
+}
+
+func (s *Source) markUnlinked(steamID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unlinked[steamID] = s.clock.Now()
+}
+
+// recordElo attributes the ELO difference since the previous poll to the
+// most recent match that was not returned by that poll
+func (s *Source) recordElo(steamID string, game PlayerGame, matches []provider.Match) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := make(map[string]bool, len(matches))
+	for _, match := range matches {
+		current[match.GameID] = true
+	}
+
+	state, found := s.players[steamID]
+	if !found {
+		s.players[steamID] = &playerState{
+			elo:     game.FaceitElo,
+			level:   game.SkillLevel,
+			matches: current,
+			changes: map[string]eloChange{},
+		}
+		return
+	}
+
+	var latest *provider.Match
+	for i, match := range matches {
+		if state.matches[match.GameID] {
+			continue
+		}
+		if latest == nil || match.GameFinishedAt.After(latest.GameFinishedAt) {
+			latest = &matches[i]
+		}
+	}
+
+	if latest != nil && state.elo > 0 && game.FaceitElo > 0 {
+		state.changes[latest.GameID] = eloChange{
+			Elo:    game.FaceitElo,
+			OldElo: state.elo,
+			Level:  game.SkillLevel,
+		}
+	}
+
+	// Forget the changes of matches that are no longer in the history
+	for matchID := range state.changes {
+		if !current[matchID] {
+			delete(state.changes, matchID)
+		}
+	}
+
+	state.elo = game.FaceitElo
+	state.level = game.SkillLevel
+	state.matches = current
+}
+
+func (s *Source) eloChange(steamID, matchID string) (eloChange, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, found := s.players[steamID]
+	if !found {
+		return eloChange{}, false
+	}
+
+	change, found := state.changes[matchID]
+	return change, found
+}
+
+func (s *Source) MatchDetails(ctx context.Context, match provider.Match) (*provider.MatchDetails, error) {
+	room, err := s.client.GetMatch(ctx, match.GameID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.client.GetMatchStats(ctx, match.GameID)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats.Rounds) == 0 {
+		return nil, fmt.Errorf("no stats for match %s", match.GameID)
+	}
+
+	return s.toMatchDetails(match, room, stats), nil
+}
+
+func (s *Source) toMatchDetails(match provider.Match, room *MatchResponse, stats *MatchStatsResponse) *provider.MatchDetails {
+	matchID := match.GameID
+	ownTeam := map[string]bool{}
+	for _, steamID := range match.OwnTeamSteam64Ids {
+		ownTeam[steamID] = true
+	}
+
+	// The scoreboard only knows FACEIT player ids, the roster links them to Steam
+	roster := map[string]RosterPlayer{}
+	for _, team := range room.Teams {
+		for _, player := range team.Roster {
+			roster[player.PlayerID] = player
+		}
+	}
+
+	round := stats.Rounds[0]
+	details := &provider.MatchDetails{
+		GameID:  matchID,
+		MapName: round.RoundStats["Map"],
+	}
+
+	var ownScore, enemyScore int
+	for i, team := range round.Teams {
+		score := atoi(team.TeamStats["Final Score"])
+		details.TeamScores = append(details.TeamScores, score)
+
+		for _, p := range team.Players {
+			rosterPlayer := roster[p.PlayerID]
+			steamID := rosterPlayer.GamePlayerID
+			if len(steamID) == 0 {
+				continue
+			}
+			if ownTeam[steamID] {
+				ownScore = score
+			} else {
+				enemyScore = score
+			}
+
+			details.Players = append(details.Players, provider.PlayerStats{
+				SteamID:           steamID,
+				Name:              p.Nickname,
+				InitialTeamNumber: i + 2,
+				Mvps:              atoi(p.PlayerStats["MVPs"]),
+				Kills:             atoi(p.PlayerStats["Kills"]),
+				Deaths:            atoi(p.PlayerStats["Deaths"]),
+				KdRatio:           atof(p.PlayerStats["K/D Ratio"]),
+				TotalDamage:       atoi(p.PlayerStats["Damage"]),
+				Assists:           atoi(p.PlayerStats["Assists"]),
+				HeadshotKills:     atoi(p.PlayerStats["Headshots"]),
+				ADR:               atof(p.PlayerStats["ADR"]),
+				DoubleKills:       atoi(p.PlayerStats["Double Kills"]),
+				TripleKills:       atoi(p.PlayerStats["Triple Kills"]),
+				QuadKills:         atoi(p.PlayerStats["Quadro Kills"]),
+				Aces:              atoi(p.PlayerStats["Penta Kills"]),
+				ClutchesWon:       atoi(p.PlayerStats["1v1Wins"]) + atoi(p.PlayerStats["1v2Wins"]),
+				FlashAssists:      atoi(p.PlayerStats["Flash Successes"]),
+				UtilityDamage:     atoi(p.PlayerStats["Utility Damage"]),
+				RoundsPlayed:      atoi(round.RoundStats["Rounds"]),
+			})
+
+			rank := provider.RankStats{
+				SteamID:  steamID,
+				RankType: provider.RankTypeFaceit,
+				Level:    rosterPlayer.GameSkillLevel,
+			}
+			if change, found := s.eloChange(steamID, matchID); found {
+				rank.Rank = change.Elo
+				rank.OldRank = change.OldElo
+				rank.RankChanged = change.Elo != change.OldElo
+				rank.Level = change.Level
+			}
+			details.Ranks = append(details.Ranks, rank)
+		}
+	}
+	details.Scores = []int{ownScore, enemyScore}
+
+	return details
+}
+
+// toMatch converts a match of the history of a player to the provider
+// model, seen from the team of that player
+func toMatch(item HistoryMatch, steamID string) (provider.Match, bool) {
+	var ownFaction string
+	for faction, team := range item.Teams {
+		for _, player := range team.Players {
+			if player.GamePlayerID == steamID {
+				ownFaction = faction
+			}
+		}
+	}
+	if len(ownFaction) == 0 {
+		return provider.Match{}, false
+	}
+
+	match := provider.Match{
+		Provider:       SourceName,
+		GameID:         item.MatchID,
+		GameFinishedAt: time.Unix(item.FinishedAt, 0).UTC(),
+		GameMode:       "Faceit",
+		DataSource:     SourceName,
+		RankType:       provider.RankTypeFaceit,
+		URL:            strings.ReplaceAll(item.FaceitURL, "{lang}", "en"),
+	}
+
+	// The history only counts the maps won by each faction, the round
+	// scores and the map are filled from the match stats
+	for faction, team := range item.Teams {
+		var steamIDs []string
+		for _, player := range team.Players {
+			steamIDs = append(steamIDs, player.GamePlayerID)
+		}
+
+		if faction == ownFaction {
+			match.OwnTeamSteam64Ids = steamIDs
+		} else {
+			match.EnemyTeamSteam64Ids = steamIDs
+		}
+	}
+
+	switch item.Results.Winner {
+	case ownFaction:
+		match.MatchResult = "win"
+	case "":
+		match.MatchResult = "tie"
+	default:
+		match.MatchResult = "loss"
+	}
+
+	return match, true
+}
+
+func atoi(value string) int {
+	n, _ := strconv.Atoi(value)
+	return n
+}
+
+func atof(value string) float64 {
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
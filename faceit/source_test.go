@@ -0,0 +1,249 @@
+package faceit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/config"
+	"github.com/mxdc/cs2-discord-bot/provider"
+)
+
+const (
+	linkedSteamID   = "76561198000000001"
+	unlinkedSteamID = "76561198000000002"
+	enemySteamID    = "76561198000000003"
+	faceitPlayerID  = "faceit-player-1"
+)
+
+// fakeAPI serves the FACEIT Data API endpoints used by the source
+type fakeAPI struct {
+	mu      sync.Mutex
+	elo     int
+	matches []HistoryMatch
+	lookups map[string]int
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *httptest.Server) {
+	api := &fakeAPI{elo: 2000, lookups: map[string]int{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /players", func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("missing API key, got %q", r.Header.Get("Authorization"))
+		}
+
+		steamID := r.URL.Query().Get("game_player_id")
+		api.lookups[steamID]++
+		if steamID != linkedSteamID {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeJSON(t, w, PlayerResponse{
+			PlayerID: faceitPlayerID,
+			Games:    map[string]PlayerGame{"cs2": {FaceitElo: api.elo, SkillLevel: 10, GamePlayerID: linkedSteamID}},
+		})
+	})
+	mux.HandleFunc("GET /players/{id}/history", func(w http.ResponseWriter, r *http.Request) {
+		api.mu.Lock()
+		defer api.mu.Unlock()
+
+		writeJSON(t, w, HistoryResponse{Items: api.matches})
+	})
+	mux.HandleFunc("GET /matches/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var match MatchResponse
+		match.MatchID = r.PathValue("id")
+		match.Teams = map[string]struct {
+			FactionID string         `json:"faction_id"`
+			Name      string         `json:"name"`
+			Roster    []RosterPlayer `json:"roster"`
+		}{
+			"faction1": {Roster: []RosterPlayer{{PlayerID: faceitPlayerID, GamePlayerID: linkedSteamID, GameSkillLevel: 10}}},
+			"faction2": {Roster: []RosterPlayer{{PlayerID: "faceit-enemy", GamePlayerID: enemySteamID, GameSkillLevel: 8}}},
+		}
+		writeJSON(t, w, match)
+	})
+	mux.HandleFunc("GET /matches/{id}/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"rounds": [{
+			"round_stats": {"Map": "de_inferno", "Rounds": "24"},
+			"teams": [
+				{"team_stats": {"Final Score": "13"}, "players": [
+					{"player_id": "faceit-player-1", "nickname": "me", "player_stats": {"Kills": "25", "Deaths": "12", "K/D Ratio": "2.08", "Headshots": "10", "ADR": "98.5"}}
+				]},
+				{"team_stats": {"Final Score": "11"}, "players": [
+					{"player_id": "faceit-enemy", "nickname": "them", "player_stats": {"Kills": "12", "Deaths": "25", "K/D Ratio": "0.48"}}
+				]}
+			]
+		}]}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return api, server
+}
+
+func (api *fakeAPI) play(matchID string, finishedAt time.Time, newElo int) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	match := HistoryMatch{
+		MatchID:    matchID,
+		Status:     "finished",
+		FinishedAt: finishedAt.Unix(),
+		FaceitURL:  "https://www.faceit.com/{lang}/cs2/room/" + matchID,
+		Teams: map[string]HistoryTeam{
+			"faction1": {Players: []HistoryPlayer{{PlayerID: faceitPlayerID, GamePlayerID: linkedSteamID}}},
+			"faction2": {Players: []HistoryPlayer{{PlayerID: "faceit-enemy", GamePlayerID: enemySteamID}}},
+		},
+	}
+	match.Results.Winner = "faction1"
+
+	// The history is sorted from the most recent match
+	api.matches = append([]HistoryMatch{match}, api.matches...)
+	api.elo = newElo
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Errorf("failed to encode response: %v", err)
+	}
+}
+
+func TestRecentMatchesAndStats(t *testing.T) {
+	api, server := newFakeAPI(t)
+	start := time.Date(2025, 1, 31, 20, 0, 0, 0, time.UTC)
+	source := NewSource(NewFaceitClient(server.URL, "key"), clock.NewSimulated(start))
+	player := config.Player{SteamID: linkedSteamID}
+	ctx := context.Background()
+
+	api.play("match-1", start.Add(-time.Hour), 2000)
+
+	matches, err := source.RecentMatches(ctx, player)
+	if err != nil {
+		t.Fatalf("RecentMatches: %v", err)
+	}
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(matches))
+	}
+
+	match := matches[0]
+	if match.GameID != "match-1" || match.Provider != SourceName || match.MatchResult != "win" {
+		t.Errorf("unexpected match %+v", match)
+	}
+	// The history only counts the maps won, the round scores come from the stats
+	if len(match.Scores) != 0 {
+		t.Errorf("got scores %v from the history, want none", match.Scores)
+	}
+	if match.URL != "https://www.faceit.com/en/cs2/room/match-1" {
+		t.Errorf("got URL %q", match.URL)
+	}
+	if len(match.EnemyTeamSteam64Ids) != 1 || match.EnemyTeamSteam64Ids[0] != enemySteamID {
+		t.Errorf("got enemy team %v", match.EnemyTeamSteam64Ids)
+	}
+
+	details, err := source.MatchDetails(ctx, match)
+	if err != nil {
+		t.Fatalf("MatchDetails: %v", err)
+	}
+	if details.MapName != "de_inferno" {
+		t.Errorf("got map %q", details.MapName)
+	}
+	if len(details.Scores) != 2 || details.Scores[0] != 13 || details.Scores[1] != 11 {
+		t.Errorf("got scores %v, want [13 11]", details.Scores)
+	}
+	if len(details.Players) != 2 {
+		t.Fatalf("got %d players, want 2", len(details.Players))
+	}
+	me := details.Players[0]
+	if me.SteamID != linkedSteamID || me.Kills != 25 || me.Deaths != 12 || me.HeadshotKills != 10 || me.ADR != 98.5 || me.RoundsPlayed != 24 {
+		t.Errorf("unexpected stats %+v", me)
+	}
+
+	// The ELO of the first poll is not attributed to any match
+	if details.Ranks[0].RankChanged {
+		t.Errorf("unexpected ELO change %+v on the first poll", details.Ranks[0])
+	}
+}
+
+func TestEloDeltaAttributedToNewMatch(t *testing.T) {
+	api, server := newFakeAPI(t)
+	start := time.Date(2025, 1, 31, 20, 0, 0, 0, time.UTC)
+	source := NewSource(NewFaceitClient(server.URL, "key"), clock.NewSimulated(start))
+	player := config.Player{SteamID: linkedSteamID}
+	ctx := context.Background()
+
+	api.play("match-1", start.Add(-2*time.Hour), 2000)
+	if _, err := source.RecentMatches(ctx, player); err != nil {
+		t.Fatalf("RecentMatches: %v", err)
+	}
+
+	api.play("match-2", start.Add(-time.Hour), 2025)
+	matches, err := source.RecentMatches(ctx, player)
+	if err != nil {
+		t.Fatalf("RecentMatches: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("got %d matches, want 2", len(matches))
+	}
+
+	for _, match := range matches {
+		details, err := source.MatchDetails(ctx, match)
+		if err != nil {
+			t.Fatalf("MatchDetails: %v", err)
+		}
+
+		rank := details.Ranks[0]
+		if rank.RankType != provider.RankTypeFaceit {
+			t.Errorf("got rank type %d", rank.RankType)
+		}
+
+		switch match.GameID {
+		case "match-1":
+			if rank.RankChanged {
+				t.Errorf("ELO change %+v attributed to the old match", rank)
+			}
+		case "match-2":
+			if !rank.RankChanged || rank.OldRank != 2000 || rank.Rank != 2025 || rank.Level != 10 {
+				t.Errorf("got rank %+v, want 2000 -> 2025 at level 10", rank)
+			}
+		}
+	}
+}
+
+func TestUnlinkedPlayerLookupCached(t *testing.T) {
+	api, server := newFakeAPI(t)
+	clk := clock.NewSimulated(time.Date(2025, 1, 31, 20, 0, 0, 0, time.UTC))
+	source := NewSource(NewFaceitClient(server.URL, "key"), clk)
+	player := config.Player{SteamID: unlinkedSteamID}
+	ctx := context.Background()
+
+	for range 3 {
+		matches, err := source.RecentMatches(ctx, player)
+		if err != nil || len(matches) != 0 {
+			t.Fatalf("got %d matches and error %v, want none", len(matches), err)
+		}
+	}
+	if api.lookups[unlinkedSteamID] != 1 {
+		t.Errorf("got %d lookups within the TTL, want 1", api.lookups[unlinkedSteamID])
+	}
+
+	clk.Advance(unlinkedTTL)
+	if _, err := source.RecentMatches(ctx, player); err != nil {
+		t.Fatalf("RecentMatches: %v", err)
+	}
+	if api.lookups[unlinkedSteamID] != 2 {
+		t.Errorf("got %d lookups after the TTL, want 2", api.lookups[unlinkedSteamID])
+	}
+}
@@ -0,0 +1,188 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/parser"
+	"github.com/mxdc/cs2-discord-bot/provider"
+	"github.com/mxdc/cs2-discord-bot/storage"
+)
+
+// RatingKey identifies a rating time series.
+// Competitive ranks are per map, MapName is empty for other rank types.
+type RatingKey struct {
+	SteamID  string
+	RankType int
+	MapName  string
+}
+
+func NewRatingKey(steamID string, rankType int, mapName string) RatingKey {
+	key := RatingKey{SteamID: steamID, RankType: rankType}
+	if rankType == provider.RankTypeCompetitive {
+		key.MapName = strings.ToLower(mapName)
+	}
+	return key
+}
+
+type RatingPoint struct {
+	SteamID   string    `json:"steamId"`
+	RankType  int       `json:"rankType"`
+	MapName   string    `json:"mapName,omitempty"`
+	GameID    string    `json:"gameId"`
+	Time      time.Time `json:"time"`
+	Rating    int       `json:"rating"`
+	OldRating int       `json:"oldRating"`
+}
+
+func (p RatingPoint) key() RatingKey {
+	return NewRatingKey(p.SteamID, p.RankType, p.MapName)
+}
+
+// Ratings holds the rating time series of the tracked players
+type Ratings struct {
+	mu     sync.RWMutex
+	path   string
+	series map[RatingKey][]RatingPoint
+}
+
+func openRatings(path string) (*Ratings, error) {
+	ratings := &Ratings{
+		path:   path,
+		series: make(map[RatingKey][]RatingPoint),
+	}
+
+	err := storage.ReadJSONLines(path, func(line []byte) error {
+		var point RatingPoint
+		if err := json.Unmarshal(line, &point); err != nil {
+			return err
+		}
+		ratings.insert(point)
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return ratings, nil
+}
+
+// insert adds the point to its series, keeping it sorted by time.
+// It returns false when the game is already part of the series.
+func (r *Ratings) insert(point RatingPoint) bool {
+	key := point.key()
+	series := r.series[key]
+
+	for _, existing := range series {
+		if existing.GameID == point.GameID {
+			return false
+		}
+	}
+
+	i, _ := slices.BinarySearchFunc(series, point.Time, func(p RatingPoint, t time.Time) int {
+		return p.Time.Compare(t)
+	})
+	r.series[key] = slices.Insert(series, i, point)
+
+	return true
+}
+
+// Record appends a rating point for every known player with a rank in the match
+func (r *Ratings) Record(match parser.MatchWithDetails) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, player := range match.OwnTeam.KnownPlayers {
+		stats := player.RankStats
+		if stats.Rank <= 0 {
+			continue
+		}
+
+		point := RatingPoint{
+			SteamID:   player.SteamID,
+			RankType:  stats.RankType,
+			GameID:    match.GameID,
+			Time:      match.GameFinishedAt,
+			Rating:    stats.Rank,
+			OldRating: stats.OldRank,
+		}
+		point.MapName = point.key().MapName
+
+		if !r.insert(point) {
+			continue
+		}
+		if err := storage.AppendJSONLine(r.path, point); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Series returns the rating points of the key from oldest to newest
+func (r *Ratings) Series(key RatingKey) []RatingPoint {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.series[key])
+}
+
+// Peak returns the highest rating ever recorded
+func (r *Ratings) Peak(key RatingKey) (RatingPoint, bool) {
+	series := r.Series(key)
+	if len(series) == 0 {
+		return RatingPoint{}, false
+	}
+
+	return slices.MaxFunc(series, func(a, b RatingPoint) int {
+		return a.Rating - b.Rating
+	}), true
+}
+
+// At returns the rating held at the given date, that is the rating
+// reached after the last match finished before it
+func (r *Ratings) At(key RatingKey, date time.Time) (int, bool) {
+	series := r.Series(key)
+
+	for i := len(series) - 1; i >= 0; i-- {
+		if !series[i].Time.After(date) {
+			return series[i].Rating, true
+		}
+	}
+
+	return 0, false
+}
+
+// Delta returns the rating won or lost between two dates.
+// When no rating is known at the start of the period, the rating held
+// before the first match of the period is used instead.
+func (r *Ratings) Delta(key RatingKey, from, to time.Time) (int, bool) {
+	end, found := r.At(key, to)
+	if !found {
+		return 0, false
+	}
+
+	if start, found := r.At(key, from); found {
+		return end - start, true
+	}
+
+	for _, point := range r.Series(key) {
+		if point.Time.After(to) {
+			break
+		}
+		if !point.Time.Before(from) {
+			start := point.OldRating
+			if start <= 0 {
+				start = point.Rating
+			}
+			return end - start, true
+		}
+	}
+
+	return 0, false
+}
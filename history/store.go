@@ -0,0 +1,187 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/parser"
+	"github.com/mxdc/cs2-discord-bot/storage"
+)
+
+const recordVersion = 1
+
+type record struct {
+	Version int                     `json:"version"`
+	SavedAt time.Time               `json:"savedAt"`
+	Match   parser.MatchWithDetails `json:"match"`
+}
+
+// Store is the local history of every enriched match.
+// Matches are appended to a JSON lines file and indexed in memory,
+// the latest record of a game wins when it is saved several times.
+// A nil store discards everything.
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	matches map[string]parser.MatchWithDetails
+	ratings *Ratings
+}
+
+// Open loads the history stored in dir
+func Open(dir string) (*Store, error) {
+	path := filepath.Join(dir, "matches.jsonl")
+	store := &Store{
+		path:    path,
+		matches: make(map[string]parser.MatchWithDetails),
+	}
+
+	err := storage.ReadJSONLines(path, func(line []byte) error {
+		var r record
+		if err := json.Unmarshal(line, &r); err != nil {
+			return err
+		}
+		store.matches[r.Match.GameID] = r.Match
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	store.ratings, err = openRatings(filepath.Join(dir, "ratings.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("History: Loaded %d matches from %s", len(store.matches), path)
+
+	return store, nil
+}
+
+// Save records the match, replacing any previous version of the same game
+func (s *Store) Save(match parser.MatchWithDetails) error {
+	if s == nil || len(match.GameID) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := record{Version: recordVersion, SavedAt: time.Now().UTC(), Match: match}
+	if err := storage.AppendJSONLine(s.path, r); err != nil {
+		return err
+	}
+	s.matches[match.GameID] = match
+
+	return s.ratings.Record(match)
+}
+
+// Ratings returns the rating time series of the tracked players
+func (s *Store) Ratings() *Ratings {
+	if s == nil {
+		return nil
+	}
+
+	return s.ratings
+}
+
+// Has reports whether the game is already part of the history
+func (s *Store) Has(gameID string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, found := s.matches[gameID]
+	return found
+}
+
+func (s *Store) Get(gameID string) (parser.MatchWithDetails, bool) {
+	if s == nil {
+		return parser.MatchWithDetails{}, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	match, found := s.matches[gameID]
+	return match, found
+}
+
+// Query filters the history, zero-valued fields match everything
+type Query struct {
+	SteamID  string
+	From     time.Time
+	To       time.Time
+	MapName  string
+	GameMode string
+}
+
+func (q Query) matches(match parser.MatchWithDetails) bool {
+	if !q.From.IsZero() && match.GameFinishedAt.Before(q.From) {
+		return false
+	}
+
+	if !q.To.IsZero() && !match.GameFinishedAt.Before(q.To) {
+		return false
+	}
+
+	if len(q.MapName) > 0 && !strings.EqualFold(q.MapName, match.MapName) {
+		return false
+	}
+
+	if len(q.GameMode) > 0 && !strings.EqualFold(q.GameMode, match.GameMode) {
+		return false
+	}
+
+	if len(q.SteamID) > 0 {
+		_, found := FindPlayer(match, q.SteamID)
+		return found
+	}
+
+	return true
+}
+
+// Find returns the matches selected by the query, from oldest to newest
+func (s *Store) Find(q Query) []parser.MatchWithDetails {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []parser.MatchWithDetails
+	for _, match := range s.matches {
+		if q.matches(match) {
+			found = append(found, match)
+		}
+	}
+
+	slices.SortFunc(found, func(a, b parser.MatchWithDetails) int {
+		return a.GameFinishedAt.Compare(b.GameFinishedAt)
+	})
+
+	return found
+}
+
+// FindPlayer returns the stats of a player in the match, whatever their team
+func FindPlayer(match parser.MatchWithDetails, steamID string) (parser.Player, bool) {
+	for _, team := range []parser.Team{match.OwnTeam, match.EnemyTeam} {
+		for _, player := range team.Players {
+			if player.SteamID == steamID {
+				return player, true
+			}
+		}
+	}
+
+	return parser.Player{}, false
+}
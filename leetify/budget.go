@@ -0,0 +1,135 @@
+package leetify
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultRequestsPerMinute is used when no budget is configured
+const DefaultRequestsPerMinute = 10
+
+// RequestBudget shares a requests-per-minute budget between every caller of
+// the Leetify API. Waiting callers are served in round-robin order of their
+// key, so one player with many pending requests cannot starve the others.
+// A nil budget never waits.
+type RequestBudget struct {
+	mu       sync.Mutex
+	interval time.Duration
+	queues   map[string][]chan struct{}
+	order    []string
+	cursor   int
+	wake     chan struct{}
+}
+
+func NewRequestBudget(requestsPerMinute int) *RequestBudget {
+	if requestsPerMinute <= 0 {
+		requestsPerMinute = DefaultRequestsPerMinute
+	}
+
+	b := &RequestBudget{
+		interval: time.Minute / time.Duration(requestsPerMinute),
+		queues:   make(map[string][]chan struct{}),
+		wake:     make(chan struct{}, 1),
+	}
+	go b.run()
+
+	return b
+}
+
+// Wait blocks until the caller identified by key may send a request
+// or the context is done
+func (b *RequestBudget) Wait(ctx context.Context, key string) error {
+	if b == nil {
+		return ctx.Err()
+	}
+
+	ready := make(chan struct{})
+
+	b.mu.Lock()
+	if len(b.queues[key]) == 0 {
+		b.order = append(b.order, key)
+	}
+	b.queues[key] = append(b.queues[key], ready)
+	b.mu.Unlock()
+
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		b.cancel(key, ready)
+		return ctx.Err()
+	}
+}
+
+// cancel removes a waiter that gave up before its turn
+func (b *RequestBudget) cancel(key string, ready chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	queue := b.queues[key]
+	i := slices.Index(queue, ready)
+	if i < 0 {
+		return
+	}
+
+	if len(queue) > 1 {
+		b.queues[key] = slices.Delete(queue, i, i+1)
+		return
+	}
+
+	delete(b.queues, key)
+	if j := slices.Index(b.order, key); j >= 0 {
+		b.order = slices.Delete(b.order, j, j+1)
+		if b.cursor > j {
+			b.cursor--
+		}
+	}
+}
+
+func (b *RequestBudget) run() {
+	for {
+		b.mu.Lock()
+		ready := b.pop()
+		b.mu.Unlock()
+
+		if ready == nil {
+			<-b.wake
+			continue
+		}
+
+		close(ready)
+		time.Sleep(b.interval)
+	}
+}
+
+// pop returns the first waiter of the next key in round-robin order
+func (b *RequestBudget) pop() chan struct{} {
+	if len(b.order) == 0 {
+		return nil
+	}
+
+	if b.cursor >= len(b.order) {
+		b.cursor = 0
+	}
+
+	key := b.order[b.cursor]
+	queue := b.queues[key]
+	ready := queue[0]
+
+	if len(queue) == 1 {
+		delete(b.queues, key)
+		b.order = append(b.order[:b.cursor], b.order[b.cursor+1:]...)
+	} else {
+		b.queues[key] = queue[1:]
+		b.cursor++
+	}
+
+	return ready
+}
@@ -0,0 +1,129 @@
+package leetify
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/storage"
+)
+
+// Cache keeps Leetify responses on disk.
+// Match details never change once their player stats are complete, so they
+// are stored forever. Profiles change after every match and are only reused
+// for a short TTL. A nil cache is disabled.
+type Cache struct {
+	dir        string
+	profileTTL time.Duration
+	clock      clock.Clock
+	// mu serializes the writes of the cache files
+	mu sync.Mutex
+}
+
+// cacheVersion is bumped when the decoded responses gain fields, entries
+// cached by a previous version are then fetched again
+const cacheVersion = 2
+
+type cachedProfile struct {
+	FetchedAt time.Time       `json:"fetchedAt"`
+	Profile   ProfileResponse `json:"profile"`
+}
+
+func NewCache(dir string, profileTTL time.Duration, clk clock.Clock) *Cache {
+	return &Cache{
+		dir:        dir,
+		profileTTL: profileTTL,
+		clock:      clk,
+	}
+}
+
+// MatchDetails returns the cached details of a game
+func (c *Cache) MatchDetails(gameID string) (*MatchDetailsResponse, bool) {
+	path, ok := c.path("matches", gameID)
+	if !ok {
+		return nil, false
+	}
+
+	var details MatchDetailsResponse
+	if err := storage.ReadJSON(path, &details); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Leetify: Ignoring cached match %s: %v", gameID, err)
+		}
+		return nil, false
+	}
+
+	return &details, true
+}
+
+// StoreMatchDetails caches the details of a game once its player stats,
+// and ranks for Premier, are complete. Incomplete details are fetched again
+// on the next request.
+func (c *Cache) StoreMatchDetails(gameID string, details *MatchDetailsResponse) {
+	if details == nil || len(details.PlayerStats) == 0 {
+		return
+	}
+	if details.DataSource == "matchmaking" && len(details.MatchmakingGameStats) == 0 {
+		return
+	}
+
+	path, ok := c.path("matches", gameID)
+	if !ok {
+		return
+	}
+
+	c.write(path, details)
+}
+
+// Profile returns the cached profile of a player if it is fresh enough
+func (c *Cache) Profile(playerID string) (ProfileResponse, bool) {
+	path, ok := c.path("profiles", playerID)
+	if !ok || c.profileTTL <= 0 {
+		return ProfileResponse{}, false
+	}
+
+	var cached cachedProfile
+	if err := storage.ReadJSON(path, &cached); err != nil {
+		return ProfileResponse{}, false
+	}
+	if c.clock.Now().Sub(cached.FetchedAt) > c.profileTTL {
+		return ProfileResponse{}, false
+	}
+
+	return cached.Profile, true
+}
+
+func (c *Cache) StoreProfile(playerID string, profile ProfileResponse) {
+	path, ok := c.path("profiles", playerID)
+	if !ok || c.profileTTL <= 0 {
+		return
+	}
+
+	c.write(path, cachedProfile{FetchedAt: c.clock.Now(), Profile: profile})
+}
+
+func (c *Cache) write(path string, v any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The cache is only an optimization, the response is still used
+	if err := storage.WriteJSON(path, v); err != nil {
+		log.Printf("Leetify: Error caching response: %v", err)
+	}
+}
+
+// path returns the cache file of a key, keys are escaped so game IDs and
+// vanity names cannot point outside of the cache directory
+func (c *Cache) path(kind, key string) (string, bool) {
+	if c == nil || len(key) == 0 || key == "." || key == ".." {
+		return "", false
+	}
+
+	version := fmt.Sprintf("v%d", cacheVersion)
+	return filepath.Join(c.dir, version, kind, url.PathEscape(key)+".json"), true
+}
@@ -1,6 +1,7 @@
 package leetify
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -12,19 +13,28 @@ import (
 )
 
 type LeetifyClient struct {
-	httpClient *http.Client
-	baseURL    string
+	httpClient  *http.Client
+	baseURL     string
+	budget      *RequestBudget
+	cache       *Cache
+	retryPolicy RetryPolicy
 }
 
-func NewLeetifyClient(baseURL string) *LeetifyClient {
+func NewLeetifyClient(baseURL string, budget *RequestBudget, cache *Cache) *LeetifyClient {
 	return &LeetifyClient{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		baseURL: baseURL,
+		baseURL:     baseURL,
+		budget:      budget,
+		cache:       cache,
+		retryPolicy: DefaultRetryPolicy,
 	}
 }
 
+// matchDetailsBudgetKey shares one round-robin slot between all match details requests
+const matchDetailsBudgetKey = "match-details"
+
 type LeetifyGameResponse struct {
 	EnemyTeamSteam64Ids []string `json:"enemyTeamSteam64Ids"`
 	OwnTeamSteam64Ids   []string `json:"ownTeamSteam64Ids"`
@@ -54,35 +64,20 @@ type ProfileResponse struct {
 	Teammates []LeetifyTeammateResponse `json:"teammates"`
 }
 
-func (c *LeetifyClient) GetPlayerMatches(playerConfig config.Player) (ProfileResponse, error) {
-	u := c.getUrlForPlayer(playerConfig)
-
-	log.Printf("Leetify: Fetching matches from %s\n", u.Path)
-
-	var emptyProfile ProfileResponse
-
-	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
-	if err != nil {
-		return emptyProfile, fmt.Errorf("Failed to create request: %w", err)
+func (c *LeetifyClient) GetPlayerMatches(ctx context.Context, playerConfig config.Player) (ProfileResponse, error) {
+	if profile, found := c.cache.Profile(playerConfig.PlayerID()); found {
+		return profile, nil
 	}
 
-	req.Header.Set("Origin", "https://leetify.com")
-	req.Header.Set("Referer", "https://leetify.com/")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return emptyProfile, fmt.Errorf("Failed to make request: %w", err)
-	}
-	defer resp.Body.Close()
+	u := c.getUrlForPlayer(playerConfig)
 
-	if resp.StatusCode != http.StatusOK {
-		return emptyProfile, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
-	}
+	log.Printf("Leetify: Fetching matches from %s\n", u.Path)
 
 	var profileResp ProfileResponse
-	if err := json.NewDecoder(resp.Body).Decode(&profileResp); err != nil {
-		return emptyProfile, fmt.Errorf("Failed to decode response: %w", err)
+	if err := c.getJSON(ctx, u, playerConfig.PlayerID(), &profileResp); err != nil {
+		return ProfileResponse{}, err
 	}
+	c.cache.StoreProfile(playerConfig.PlayerID(), profileResp)
 
 	return profileResp, nil
 }
@@ -122,37 +117,69 @@ type LeetifyPlayerStats struct {
 	TotalDeaths       int       `json:"totalDeaths"`
 	KdRatio           float64   `json:"kdRatio"`
 	TotalDamage       int       `json:"totalDamage"`
+	TotalAssists      int       `json:"totalAssists"`
+	TotalHsKills      int       `json:"totalHsKills"`
+	RoundsCount       int       `json:"roundsCount"`
+	LeetifyRating     float64   `json:"leetifyRating"`
+	HltvRating        float64   `json:"hltvRating"`
+	Multi2k           int       `json:"multi2k"`
+	Multi3k           int       `json:"multi3k"`
+	Multi4k           int       `json:"multi4k"`
+	Multi5k           int       `json:"multi5k"`
+	ClutchesWon       int       `json:"clutchesWon"`
+	FlashAssist       int       `json:"flashAssist"`
+	TradeKillsSucceed int       `json:"tradeKillsSucceeded"`
 }
 
-func (c *LeetifyClient) GetMatchDetails(gameID string) (*MatchDetailsResponse, error) {
-	u := c.getUrlForGameID(gameID)
-
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Origin", "https://leetify.com")
-	req.Header.Set("Referer", "https://leetify.com/")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+func (c *LeetifyClient) GetMatchDetails(ctx context.Context, gameID string) (*MatchDetailsResponse, error) {
+	if details, found := c.cache.MatchDetails(gameID); found {
+		return details, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("match details request failed with status: %d", resp.StatusCode)
-	}
+	u := c.getUrlForGameID(gameID)
 
 	var details MatchDetailsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
-		return nil, fmt.Errorf("failed to decode match details response: %w", err)
+	if err := c.getJSON(ctx, u, matchDetailsBudgetKey, &details); err != nil {
+		return nil, fmt.Errorf("match details request failed: %w", err)
 	}
+	c.cache.StoreMatchDetails(gameID, &details)
 
 	return &details, nil
 }
 
+// getJSON sends a GET request within the request budget and decodes the
+// response into v, retrying according to the retry policy
+func (c *LeetifyClient) getJSON(ctx context.Context, u *url.URL, budgetKey string, v any) error {
+	return c.retryPolicy.withRetry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+
+		req.Header.Set("Origin", "https://leetify.com")
+		req.Header.Set("Referer", "https://leetify.com/")
+
+		if err := c.budget.Wait(ctx, budgetKey); err != nil {
+			return err
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to make request: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return newAPIError(resp)
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		return nil
+	})
+}
+
 func (c *LeetifyClient) getUrlForPlayer(playerConfig config.Player) *url.URL {
 	u, err := url.Parse(c.baseURL)
 	if err != nil {
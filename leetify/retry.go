@@ -0,0 +1,72 @@
+package leetify
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt, 1 disables retries
+	MaxAttempts int
+	BaseDelay   time.Duration
+	// MaxDelay bounds the backoff, a longer Retry-After gives up instead of waiting
+	MaxDelay time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   10 * time.Second,
+	MaxDelay:    5 * time.Minute,
+}
+
+// delay returns how long to wait before the given retry attempt (1 for the
+// first retry) and whether the request should be retried at all
+func (p RetryPolicy) delay(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || !IsRetryable(err) {
+		return 0, false
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > p.MaxDelay {
+			return 0, false
+		}
+		delay = max(delay, apiErr.RetryAfter)
+	}
+
+	return delay, true
+}
+
+// withRetry calls fn until it succeeds, fails permanently or the policy gives up
+func (p RetryPolicy) withRetry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		delay, retry := p.delay(attempt, err)
+		if !retry {
+			return err
+		}
+
+		log.Printf("Leetify: Request failed (%v), retrying in %s", err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package leetify
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/config"
+	"github.com/mxdc/cs2-discord-bot/provider"
+)
+
+const SourceName = "leetify"
+
+// Source exposes Leetify as a provider.MatchSource
+type Source struct {
+	client *LeetifyClient
+	// excluded data sources are left to another provider, such as "faceit"
+	excluded []string
+}
+
+func NewSource(client *LeetifyClient, excludedDataSources ...string) *Source {
+	return &Source{client: client, excluded: excludedDataSources}
+}
+
+func (s *Source) Name() string {
+	return SourceName
+}
+
+func (s *Source) RecentMatches(ctx context.Context, player config.Player) ([]provider.Match, error) {
+	profile, err := s.client.GetPlayerMatches(ctx, player)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]provider.Match, 0, len(profile.Games))
+	for _, game := range profile.Games {
+		if slices.Contains(s.excluded, game.DataSource) {
+			continue
+		}
+		matches = append(matches, ToMatch(game))
+	}
+
+	return matches, nil
+}
+
+func (s *Source) MatchDetails(ctx context.Context, match provider.Match) (*provider.MatchDetails, error) {
+	details, err := s.client.GetMatchDetails(ctx, match.GameID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toMatchDetails(details), nil
+}
+
+// GameMode returns the game mode matching a Leetify data source
+func GameMode(dataSource string) string {
+	switch dataSource {
+	case "matchmaking_competitive":
+		return "Competitive"
+	case "matchmaking":
+		return "Premier"
+	case "faceit":
+		return "Faceit"
+	default:
+		return "unknown"
+	}
+}
+
+// ToMatch converts a game of a Leetify profile to the provider model
+func ToMatch(game LeetifyGameResponse) provider.Match {
+	gameTime, _ := time.Parse(time.RFC3339, game.GameFinishedAt)
+
+	return provider.Match{
+		Provider:            SourceName,
+		GameID:              game.GameId,
+		GameFinishedAt:      gameTime,
+		GameMode:            GameMode(game.DataSource),
+		DataSource:          game.DataSource,
+		MapName:             game.MapName,
+		MatchResult:         game.MatchResult,
+		RankType:            game.RankType,
+		Scores:              game.Scores,
+		OwnTeamSteam64Ids:   game.OwnTeamSteam64Ids,
+		EnemyTeamSteam64Ids: game.EnemyTeamSteam64Ids,
+		URL:                 fmt.Sprintf("https://leetify.com/public/match-details/%s/details-general", game.GameId),
+	}
+}
+
+func toMatchDetails(details *MatchDetailsResponse) *provider.MatchDetails {
+	result := &provider.MatchDetails{
+		GameID:     details.ID,
+		MapName:    details.MapName,
+		ShareCode:  details.SteamShareCode,
+		TeamScores: details.TeamScores,
+	}
+
+	for _, p := range details.PlayerStats {
+		result.Players = append(result.Players, provider.PlayerStats{
+			SteamID:           p.Steam64ID,
+			Name:              p.Name,
+			InitialTeamNumber: p.InitialTeamNumber,
+			Score:             p.Score,
+			Mvps:              p.Mvps,
+			Kills:             p.TotalKills,
+			Deaths:            p.TotalDeaths,
+			KdRatio:           p.KdRatio,
+			TotalDamage:       p.TotalDamage,
+			Assists:           p.TotalAssists,
+			HeadshotKills:     p.TotalHsKills,
+			ADR:               averageDamage(p.TotalDamage, p.RoundsCount),
+			LeetifyRating:     p.LeetifyRating,
+			HltvRating:        p.HltvRating,
+			DoubleKills:       p.Multi2k,
+			TripleKills:       p.Multi3k,
+			QuadKills:         p.Multi4k,
+			Aces:              p.Multi5k,
+			ClutchesWon:       p.ClutchesWon,
+			FlashAssists:      p.FlashAssist,
+			TradeKills:        p.TradeKillsSucceed,
+			RoundsPlayed:      p.RoundsCount,
+		})
+	}
+
+	for _, r := range details.MatchmakingGameStats {
+		result.Ranks = append(result.Ranks, provider.RankStats{
+			SteamID:     r.SteamID,
+			Rank:        r.Rank,
+			OldRank:     r.OldRank,
+			RankType:    r.RankType,
+			RankChanged: r.RankChanged,
+			Wins:        r.Wins,
+		})
+	}
+
+	return result
+}
+
+// averageDamage returns the damage per round, 0 when no round was played
+func averageDamage(totalDamage, rounds int) float64 {
+	if rounds == 0 {
+		return 0
+	}
+
+	return float64(totalDamage) / float64(rounds)
+}
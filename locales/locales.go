@@ -1,6 +1,7 @@
 package locales
 
 import (
+	"fmt"
 	"log"
 	"os"
 
@@ -17,6 +18,8 @@ type Translations struct {
 	WinSingleRank              string `yaml:"win_single_rank"`
 	LossSingle                 string `yaml:"loss_single"`
 	LossSingleRank             string `yaml:"loss_single_rank"`
+	WinSingleElo               string `yaml:"win_single_elo"`
+	LossSingleElo              string `yaml:"loss_single_elo"`
 	TieSingle                  string `yaml:"tie_single"`
 	WinMultiple                string `yaml:"win_multiple"`
 	LossMultiple               string `yaml:"loss_multiple"`
@@ -32,32 +35,43 @@ type Translations struct {
 	SessionSingleTie           string `yaml:"session_single_tie"`
 	SessionSingleAllLossesRank string `yaml:"session_single_all_losses_rank"`
 	SessionSingleAllWinsRank   string `yaml:"session_single_all_wins_rank"`
+	BanVac                     string `yaml:"ban_vac"`
+	BanGame                    string `yaml:"ban_game"`
 }
 
 type TranslationConfigFile struct {
 	Keys []Translations `yaml:"keys"`
 }
 
-func MustLoadTranslations(path, lang string) Translations {
+// LoadTranslations reads the translations of the given language
+func LoadTranslations(path, lang string) (Translations, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("Unable to read translation file at: %s", path)
+		return Translations{}, fmt.Errorf("unable to read translation file at: %s", path)
 	}
 
 	// Unmarshal the YAML into our global map
 	var translations TranslationConfigFile
 	err = yaml.Unmarshal(data, &translations)
 	if err != nil {
-		log.Fatalf("Unable to parse translation file at: %s", path)
+		return Translations{}, fmt.Errorf("unable to parse translation file at: %s", path)
 	}
 
 	// find the translations for the specified language
 	for _, t := range translations.Keys {
 		if t.Lang == lang {
-			return t
+			return t, nil
 		}
 	}
 
-	log.Fatalf("No translations found for language: %s", lang)
-	return Translations{}
+	return Translations{}, fmt.Errorf("no translations found for language: %s", lang)
+}
+
+func MustLoadTranslations(path, lang string) Translations {
+	translations, err := LoadTranslations(path, lang)
+	if err != nil {
+		log.Fatalf("Locales: %v", err)
+	}
+
+	return translations
 }
@@ -1,110 +1,425 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/mxdc/cs2-discord-bot/banwatch"
+	"github.com/mxdc/cs2-discord-bot/clock"
 	"github.com/mxdc/cs2-discord-bot/config"
 	"github.com/mxdc/cs2-discord-bot/crawler"
+	"github.com/mxdc/cs2-discord-bot/faceit"
+	"github.com/mxdc/cs2-discord-bot/history"
 	"github.com/mxdc/cs2-discord-bot/leetify"
 	"github.com/mxdc/cs2-discord-bot/locales"
 	"github.com/mxdc/cs2-discord-bot/mistral"
+	"github.com/mxdc/cs2-discord-bot/provider"
 	"github.com/mxdc/cs2-discord-bot/session"
+	"github.com/mxdc/cs2-discord-bot/steam"
+	"github.com/mxdc/cs2-discord-bot/suspects"
 )
 
-func getTrackedPlayers(players []config.Player) []config.Player {
-	tracked := []config.Player{}
+// newSources creates the match sources enabled by the configuration
+func newSources(cfg *config.AppConfig, cache *leetify.Cache, clk clock.Clock) *provider.Registry {
+	budget := leetify.NewRequestBudget(cfg.LeetifyRequestsPerMinute)
+	client := leetify.NewLeetifyClient(cfg.LeetifyAPIURL, budget, cache)
 
-	for _, player := range players {
-		if player.Track {
-			tracked = append(tracked, player)
+	if len(cfg.FaceitAPIKey) == 0 {
+		return provider.NewRegistry(leetify.NewSource(client))
+	}
+
+	// FACEIT matches are announced from the FACEIT API, not from Leetify
+	log.Println("CS2: FACEIT provider enabled")
+	return provider.NewRegistry(
+		leetify.NewSource(client, faceit.SourceName),
+		faceit.NewSource(faceit.NewFaceitClient(cfg.FaceitAPIURL, cfg.FaceitAPIKey), clk),
+	)
+}
+
+func newLeetifyCache(dataDir string, profileTTL time.Duration, clk clock.Clock) *leetify.Cache {
+	if len(dataDir) == 0 {
+		return nil
+	}
+
+	return leetify.NewCache(filepath.Join(dataDir, "cache", "leetify"), profileTTL, clk)
+}
+
+func newGameStore(dataDir string, retention time.Duration, clk clock.Clock) session.GameStore {
+	if len(dataDir) == 0 {
+		log.Println("CS2: No data directory configured, seen games are kept in memory")
+		return session.NewSeenGames()
+	}
+
+	store, err := session.NewFileSeenGames(filepath.Join(dataDir, "seen_games.json"), retention, clk)
+	if err != nil {
+		log.Fatalf("CS2: Error loading seen games: %v", err)
+	}
+	return store
+}
+
+func newSessionCheckpoint(dataDir string) *session.SessionCheckpoint {
+	if len(dataDir) == 0 {
+		return nil
+	}
+
+	return session.NewSessionCheckpoint(filepath.Join(dataDir, "session.json"))
+}
+
+func newPersonaCache(dataDir string, ttl time.Duration, clk clock.Clock) *steam.PersonaCache {
+	path := ""
+	if len(dataDir) > 0 {
+		path = filepath.Join(dataDir, "steam_personas.json")
+	}
+
+	cache, err := steam.NewPersonaCache(path, ttl, clk)
+	if err != nil {
+		log.Fatalf("CS2: Error loading Steam personas: %v", err)
+	}
+	return cache
+}
+
+func newVanityCache(dataDir string) *steam.VanityCache {
+	path := ""
+	if len(dataDir) > 0 {
+		path = filepath.Join(dataDir, "steam_vanity.json")
+	}
+
+	cache, err := steam.NewVanityCache(path)
+	if err != nil {
+		log.Fatalf("CS2: Error loading Steam vanity names: %v", err)
+	}
+	return cache
+}
+
+// resolvePlayers fills the Steam ID of players configured with an account
+// name only. Names are resolved from the vanity cache first, and when a
+// lookup fails the Steam ID of the previous configuration is kept so a
+// reload does not change the player and restart its crawlers.
+func resolvePlayers(ctx context.Context, cfg *config.AppConfig, vanities *steam.VanityCache, previous []config.Player) {
+	previousIDs := make(map[string]string, len(previous))
+	for _, player := range previous {
+		if len(player.AccountName) > 0 && len(player.SteamID) > 0 {
+			previousIDs[player.AccountName] = player.SteamID
 		}
 	}
-	return tracked
+
+	steamClient := steam.NewSteamClient(cfg.SteamAPIKey, cfg.SteamAPIURL)
+	cfg.ResolvePlayers(func(accountName string) (string, error) {
+		steamID, err := vanities.Resolve(ctx, steamClient, accountName)
+		if err != nil {
+			if previousID, found := previousIDs[accountName]; found {
+				log.Printf("CS2: Warning: unable to resolve %q, keeping Steam ID %s: %v", accountName, previousID, err)
+				return previousID, nil
+			}
+		}
+		return steamID, err
+	})
 }
 
-func startMatchNotifier(
-	cfg *config.AppConfig,
-	client *leetify.LeetifyClient,
-	mistralClient *mistral.MistralClient,
-	translations locales.Translations,
-	debugMode bool,
-) {
-	log.Printf("CS2: Running in match mode with lang: %s", cfg.Lang)
+func newBanWatcher(dataDir string, interval, retention time.Duration, clk clock.Clock) *banwatch.Watcher {
+	if interval <= 0 {
+		return nil
+	}
 
-	matchChan := make(chan session.MatchDetected, 1024)
+	path := ""
+	if len(dataDir) > 0 {
+		path = filepath.Join(dataDir, "banwatch.json")
+	}
+
+	watcher, err := banwatch.NewWatcher(path, retention, clk)
+	if err != nil {
+		log.Fatalf("CS2: Error loading ban watch: %v", err)
+	}
+	return watcher
+}
+
+func openHistory(dataDir string) *history.Store {
+	if len(dataDir) == 0 {
+		return nil
+	}
 
-	matchNotifier := session.NewMatchNotifier(cfg, client, mistralClient, translations, matchChan)
-	go matchNotifier.HandleMatch()
+	// The history is optional, the bot runs without it rather than not at all
+	store, err := history.Open(filepath.Join(dataDir, "history"))
+	if err != nil {
+		log.Printf("CS2: Warning: match history disabled, failed to load it: %v", err)
+		return nil
+	}
+	return store
+}
 
-	startCrawlers(client, cfg, matchChan, debugMode)
+// bot holds the dependencies shared by the crawlers and the notifiers
+type bot struct {
+	settings      *session.LiveSettings
+	sources       *provider.Registry
+	mistralClient *mistral.MistralClient
+	seenGames     session.GameStore
+	history       *history.Store
+	personas      *steam.PersonaCache
+	vanities      *steam.VanityCache
+	bans          *banwatch.Watcher
+	suspects      *suspects.Detector
+	clock         clock.Clock
+	dataDir       string
+	withRank      bool
+	debugMode     bool
 }
 
-func startSessionNotifier(
-	cfg *config.AppConfig,
-	client *leetify.LeetifyClient,
-	mistralClient *mistral.MistralClient,
-	translations locales.Translations,
-	withRank bool,
-	debugMode bool,
-) {
-	log.Printf("CS2: Running in session mode with lang: %s", cfg.Lang)
+func (b *bot) startMatchNotifier(ctx context.Context, wg *sync.WaitGroup, matchChan <-chan session.MatchDetected) {
+	log.Printf("CS2: Running in match mode with lang: %s", b.settings.Load().Config.Lang)
+
+	matchNotifier := session.NewMatchNotifier(
+		b.settings, b.sources, b.mistralClient, b.seenGames, b.history, b.personas, b.bans, b.suspects, b.clock, matchChan, b.withRank,
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		matchNotifier.HandleMatch(ctx)
+	}()
+}
+
+func (b *bot) startSessionNotifier(ctx context.Context, wg *sync.WaitGroup, matchChan <-chan session.MatchDetected) {
+	log.Printf("CS2: Running in session mode with lang: %s", b.settings.Load().Config.Lang)
 
-	matchChan := make(chan session.MatchDetected, 1024)
 	sessionChan := make(chan session.GameSession, 256)
 
-	sessionMgr := session.NewSessionManager(matchChan, sessionChan, debugMode)
-	go sessionMgr.HandleIncomingMatches()
+	sessionMgr := session.NewSessionManager(
+		matchChan, sessionChan, b.seenGames, newSessionCheckpoint(b.dataDir), b.clock, b.debugMode,
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sessionMgr.HandleIncomingMatches(ctx)
+	}()
 
-	sessionNotifier := session.NewSessionNotifier(cfg, client, mistralClient, translations, sessionChan, withRank)
-	go sessionNotifier.HandleSession()
+	sessionNotifier := session.NewSessionNotifier(
+		b.settings, b.sources, b.mistralClient, b.history, b.personas, b.bans, b.clock, sessionChan, b.withRank,
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sessionNotifier.HandleSession(ctx)
+	}()
+}
 
-	startCrawlers(client, cfg, matchChan, debugMode)
+func (b *bot) startBanNotifier(ctx context.Context, wg *sync.WaitGroup, interval time.Duration) {
+	if b.bans == nil {
+		return
+	}
+
+	banNotifier := session.NewBanNotifier(b.settings, b.bans, b.clock, interval)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		banNotifier.Run(ctx)
+	}()
 }
 
-func startCrawlers(client *leetify.LeetifyClient, cfg *config.AppConfig, matchChan chan<- session.MatchDetected, debugMode bool) {
-	log.Println("CS2: Starting crawler")
+// reload applies a new configuration file: notifier settings are swapped
+// and crawlers are started or stopped to match the tracked players
+func (b *bot) reload(ctx context.Context, pool *crawler.Pool, configFile, translationFile string) {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		log.Printf("CS2: Config not reloaded: %v", err)
+		return
+	}
+	resolvePlayers(ctx, cfg, b.vanities, b.settings.Load().Config.Players)
 
-	trackedPlayers := getTrackedPlayers(cfg.Players)
-	for i, player := range trackedPlayers {
-		crawler := crawler.NewCrawler(client, player, matchChan, debugMode)
-		go crawler.StartCrawling()
-		if i < len(trackedPlayers)-1 {
-			time.Sleep(5 * time.Minute)
-		}
+	translations, err := locales.LoadTranslations(translationFile, cfg.Lang)
+	if err != nil {
+		log.Printf("CS2: Config not reloaded: %v", err)
+		return
 	}
 
-	log.Println("CS2: Crawler started")
-	log.Printf("CS2: Tracking matches for %d player(s)", len(trackedPlayers))
+	previous := b.settings.Load().Config
+	if cfg.LeetifyAPIURL != previous.LeetifyAPIURL ||
+		cfg.LeetifyRequestsPerMinute != previous.LeetifyRequestsPerMinute ||
+		cfg.FaceitAPIKey != previous.FaceitAPIKey ||
+		cfg.FaceitAPIURL != previous.FaceitAPIURL ||
+		cfg.MistralAPIKey != previous.MistralAPIKey {
+		log.Println("CS2: Warning: Leetify, FACEIT and Mistral settings are only applied on restart")
+	}
+
+	b.settings.Store(cfg, translations)
+	pool.Sync(ctx, cfg.TrackedPlayers())
+	log.Printf("CS2: Config reloaded with lang: %s", cfg.Lang)
+}
+
+func newClock(simulateSpeed float64) clock.Clock {
+	if simulateSpeed <= 0 {
+		return clock.Real()
+	}
+
+	log.Printf("CS2: Simulate mode, time runs %gx faster", simulateSpeed)
+	return clock.NewScaled(simulateSpeed)
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		runBackfill(os.Args[2:])
+		return
+	}
+
 	configFile := flag.String("config.file", "config.yml", "Path to the configuration file")
 	sessionMode := flag.Bool("session", false, "Enable session mode (groups matches into sessions)")
 	debugMode := flag.Bool("debug", false, "Enable debug mode")
 	withAi := flag.Bool("with.ai", false, "Enable AI mode")
 	withRank := flag.Bool("with.rank", false, "Display new rank after each match")
+	withSuspects := flag.Bool("with.suspects", false, "Flag suspicious opponents after each match (match mode, requires a Steam API key)")
 	promptFilePath := flag.String("prompt.file", "prompts/system.md", "Path to the system prompt file")
 	translationFilePath := flag.String("translation.file", "translations.yml", "Path to the translation file")
+	dataDir := flag.String("data.dir", "data", "Directory where the bot state is persisted (empty to keep it in memory)")
+	storeRetention := flag.Duration("store.retention", 30*24*time.Hour, "How long announced games are remembered")
+	shutdownTimeout := flag.Duration("shutdown.timeout", 1*time.Minute, "How long pending notifications are drained on shutdown")
+	configWatchInterval := flag.Duration("config.watch.interval", 10*time.Second, "How often the config file is checked for changes (0 to disable)")
+	healthReportInterval := flag.Duration("health.report.interval", 1*time.Hour, "How often a summary of the crawlers health is logged (0 to disable)")
+	personaCacheTTL := flag.Duration("steam.cache.ttl", 24*time.Hour, "How long Steam names, countries and avatars are used before being refreshed")
+	banWatchInterval := flag.Duration("banwatch.interval", 6*time.Hour, "How often the bans of opponents are checked (0 to disable)")
+	banWatchRetention := flag.Duration("banwatch.retention", 90*24*time.Hour, "How long opponents are watched after the last match against them")
+	simulateSpeed := flag.Float64("simulate.speed", 0, "Simulate mode: run the crawlers and sessions clock this many times faster")
 	flag.Parse()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg := config.MustLoadConfig(*configFile)
+	vanities := newVanityCache(*dataDir)
+	resolvePlayers(ctx, cfg, vanities, nil)
 	translations := locales.MustLoadTranslations(*translationFilePath, cfg.Lang)
-	client := leetify.NewLeetifyClient(cfg.LeetifyAPIURL)
+	clk := newClock(*simulateSpeed)
+	// Crawlers poll for new matches, a cached profile would only delay them:
+	// the bot caches match details but never profiles
+	sources := newSources(cfg, newLeetifyCache(*dataDir, 0, clk), clk)
 
 	var mistralClient *mistral.MistralClient
 	if *withAi {
 		mistralClient = mistral.NewMistralClient(cfg.MistralAPIKey, *promptFilePath)
 	}
 
+	var detector *suspects.Detector
+	if *withSuspects {
+		detector = suspects.NewDetector(clk)
+	}
+
+	b := &bot{
+		settings:      session.NewLiveSettings(cfg, translations),
+		sources:       sources,
+		mistralClient: mistralClient,
+		seenGames:     newGameStore(*dataDir, *storeRetention, clk),
+		history:       openHistory(*dataDir),
+		personas:      newPersonaCache(*dataDir, *personaCacheTTL, clk),
+		vanities:      vanities,
+		bans:          newBanWatcher(*dataDir, *banWatchInterval, *banWatchRetention, clk),
+		suspects:      detector,
+		clock:         clk,
+		dataDir:       *dataDir,
+		withRank:      *withRank,
+		debugMode:     *debugMode,
+	}
+
+	// Notifiers get their own context so pending notifications can be
+	// drained after the crawlers have been stopped
+	notifyCtx, cancelNotify := context.WithCancel(context.Background())
+	defer cancelNotify()
+
+	var notifiers sync.WaitGroup
+	matchChan := make(chan session.MatchDetected, 1024)
+
 	if *sessionMode {
-		startSessionNotifier(cfg, client, mistralClient, translations, *withRank, *debugMode)
+		b.startSessionNotifier(notifyCtx, &notifiers, matchChan)
 	} else {
-		startMatchNotifier(cfg, client, mistralClient, translations, *debugMode)
+		b.startMatchNotifier(notifyCtx, &notifiers, matchChan)
+	}
+
+	log.Println("CS2: Starting crawler")
+	crawlers := crawler.NewPool(sources.Sources(), matchChan, b.seenGames, b.clock, b.debugMode)
+	crawlers.Sync(ctx, cfg.TrackedPlayers())
+	if *healthReportInterval > 0 {
+		go crawlers.ReportHealth(ctx, *healthReportInterval)
 	}
+	log.Println("CS2: Crawler started")
+
+	// Ban checks stop with the crawlers, shutdown waits for an alert being sent
+	b.startBanNotifier(ctx, &notifiers, *banWatchInterval)
 
 	log.Printf("CS2: Discord webhook configured: %t", cfg.DiscordHook != "")
 
-	select {} // block forever
+	reloads := watchReloads(ctx, *configFile, *configWatchInterval)
+running:
+	for {
+		select {
+		case <-reloads:
+			b.reload(ctx, crawlers, *configFile, *translationFilePath)
+		case <-ctx.Done():
+			break running
+		}
+	}
+
+	stop()
+	log.Println("CS2: Shutting down, stopping crawlers")
+	crawlers.Wait()
+	close(matchChan)
+
+	shutdown(&notifiers, cancelNotify, *shutdownTimeout)
+}
+
+// watchReloads merges config file changes and SIGHUP signals
+func watchReloads(ctx context.Context, configFile string, interval time.Duration) <-chan struct{} {
+	reloads := make(chan struct{}, 1)
+
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	var changes <-chan struct{}
+	if interval > 0 {
+		changes = config.Watch(ctx, configFile, interval)
+	}
+
+	go func() {
+		defer signal.Stop(hangups)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangups:
+				log.Println("CS2: SIGHUP received, reloading config")
+			case <-changes:
+				log.Println("CS2: Config file changed, reloading config")
+			}
+
+			select {
+			case reloads <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return reloads
+}
+
+// shutdown waits for the notifiers to drain pending notifications,
+// cancelling them once the timeout is reached
+func shutdown(notifiers *sync.WaitGroup, cancelNotify context.CancelFunc, timeout time.Duration) {
+	log.Printf("CS2: Draining pending notifications (timeout: %s)", timeout)
+
+	done := make(chan struct{})
+	go func() {
+		notifiers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("CS2: Shutdown complete")
+	case <-time.After(timeout):
+		log.Println("CS2: Shutdown timeout reached, cancelling pending notifications")
+		cancelNotify()
+		<-done
+	}
 }
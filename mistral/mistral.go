@@ -2,6 +2,7 @@ package mistral
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -72,13 +73,13 @@ type MistralResponse struct {
 	Usage   Usage    `json:"usage"`
 }
 
-func (mc *MistralClient) postPrompt(message string) (*MistralResponse, error) {
+func (mc *MistralClient) postPrompt(ctx context.Context, message string) (*MistralResponse, error) {
 	requestBody, err := mc.formatRequestBody(message)
 	if err != nil {
 		return nil, fmt.Errorf("failed to format request body: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", mc.baseURL+"/v1/chat/completions", bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", mc.baseURL+"/v1/chat/completions", bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -109,8 +110,8 @@ func (mc *MistralClient) postPrompt(message string) (*MistralResponse, error) {
 	return &mistralResp, nil
 }
 
-func (mc *MistralClient) GetGeneratedTitles(message string) string {
-	resp, err := mc.postPrompt(message)
+func (mc *MistralClient) GetGeneratedTitles(ctx context.Context, message string) string {
+	resp, err := mc.postPrompt(ctx, message)
 	if err != nil {
 		log.Printf("MistralClient: Error generating titles: %v", err)
 		return ""
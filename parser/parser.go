@@ -2,14 +2,16 @@ package parser
 
 import (
 	"fmt"
+	"log"
 	"slices"
 	"strings"
 	"time"
 	"unicode"
 
 	"github.com/mxdc/cs2-discord-bot/config"
-	"github.com/mxdc/cs2-discord-bot/leetify"
+	"github.com/mxdc/cs2-discord-bot/provider"
 	"github.com/mxdc/cs2-discord-bot/steam"
+	"github.com/mxdc/cs2-discord-bot/steam/sharecode"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -17,9 +19,10 @@ import (
 type PlayerRankStats struct {
 	Rank        int
 	OldRank     int
-	RankType    int // 11 for Premier Rank, 12 for Classic Matchmaking
+	RankType    int // 11 for Premier Rank, 12 for Classic Matchmaking, 100 for FACEIT ELO
 	RankChanged bool
 	Wins        int
+	Level       int // FACEIT skill level
 }
 
 type Player struct {
@@ -32,6 +35,45 @@ type Player struct {
 	KdRatio     float64
 	TotalDamage int
 	RankStats   PlayerRankStats
+	// Detailed stats, zero when the source does not provide them
+	Assists       int
+	HeadshotKills int
+	AvgDamage     float64
+	LeetifyRating float64
+	HltvRating    float64
+	DoubleKills   int
+	TripleKills   int
+	QuadKills     int
+	Aces          int
+	ClutchesWon   int
+	FlashAssists  int
+	UtilityDamage int
+	TradeKills    int
+	RoundsPlayed  int
+}
+
+// ADR returns the average damage per round, computed from the total damage
+// when the source does not provide it
+func (p *Player) ADR() float64 {
+	if p.AvgDamage > 0 || p.RoundsPlayed == 0 {
+		return p.AvgDamage
+	}
+
+	return float64(p.TotalDamage) / float64(p.RoundsPlayed)
+}
+
+// HeadshotPercentage returns the share of kills that were headshots, from 0 to 100
+func (p *Player) HeadshotPercentage() float64 {
+	if p.Kills == 0 {
+		return 0
+	}
+
+	return 100 * float64(p.HeadshotKills) / float64(p.Kills)
+}
+
+// MultiKills returns the number of rounds with at least two kills
+func (p *Player) MultiKills() int {
+	return p.DoubleKills + p.TripleKills + p.QuadKills + p.Aces
 }
 
 func (p *Player) GetRecentPremierRank() (int, int) {
@@ -48,6 +90,16 @@ func (p *Player) GetRecentPremierRank() (int, int) {
 	return oldRank, newRank
 }
 
+// GetRecentFaceitElo returns the ELO before and after the match and the FACEIT level
+func (p *Player) GetRecentFaceitElo() (int, int, int) {
+	rankStats := p.RankStats
+	if rankStats.RankType != provider.RankTypeFaceit || !rankStats.RankChanged || rankStats.Rank == 0 {
+		return 0, 0, rankStats.Level
+	}
+
+	return rankStats.OldRank, rankStats.Rank, rankStats.Level
+}
+
 func (p *Player) FormatPlayerLink(withFlag, asTitle bool) string {
 	var playerName string
 
@@ -94,12 +146,27 @@ type Team struct {
 
 type MatchWithDetails struct {
 	GameID         string
+	Provider       string
+	MatchURL       string
 	GameMode       string
 	GameFinishedAt time.Time
 	MapName        string
 	OwnTeam        Team
 	EnemyTeam      Team
 	Winner         int
+	// ShareCode of the match demo, empty when unknown or invalid
+	ShareCode string
+	// Suspects are the opponents flagged by the suspects package
+	Suspects []Suspect `json:",omitempty"`
+}
+
+// Suspect is an opponent whose Steam profile or stats look unusual
+type Suspect struct {
+	SteamID string
+	Name    string
+	KdRatio float64
+	// Reasons why the opponent was flagged, e.g. "private profile"
+	Reasons []string
 }
 
 func (m *MatchWithDetails) Defeat() bool {
@@ -118,27 +185,51 @@ func (m *MatchWithDetails) IsPremierMode() bool {
 	return m.GameMode == "Premier"
 }
 
+func (m *MatchWithDetails) IsFaceitMode() bool {
+	return m.GameMode == "Faceit"
+}
+
 func (m *MatchWithDetails) GetMatchLink() string {
+	if len(m.MatchURL) > 0 {
+		return m.MatchURL
+	}
+
 	return fmt.Sprintf("https://leetify.com/public/match-details/%s/details-general", m.GameID)
 }
 
+// GetReplayLink returns the steam:// link opening the demo of the match in
+// CS2, or an empty string when the share code is unknown
+func (m *MatchWithDetails) GetReplayLink() string {
+	code, err := sharecode.Decode(m.ShareCode)
+	if err != nil {
+		return ""
+	}
+
+	return code.ReplayLink()
+}
+
+// GetOneLinerResult formats the mode, score and map of the match, the
+// score and the map are left out while the source does not know them
 func (m *MatchWithDetails) GetOneLinerResult() string {
-	return fmt.Sprintf(
-		"%s · %d-%d · %s",
-		m.GameMode,
-		m.OwnTeam.Score,
-		m.EnemyTeam.Score,
-		m.MapName,
-	)
+	parts := []string{m.GameMode}
+	if m.OwnTeam.Score > 0 || m.EnemyTeam.Score > 0 {
+		parts = append(parts, fmt.Sprintf("%d-%d", m.OwnTeam.Score, m.EnemyTeam.Score))
+	}
+	if len(m.MapName) > 0 {
+		parts = append(parts, m.MapName)
+	}
+
+	return strings.Join(parts, " · ")
 }
 
 type MatchResult struct {
 	GameID              string
+	Provider            string
+	URL                 string
 	OwnTeamSteam64Ids   []string
 	EnemyTeamSteam64Ids []string
 	DataSource          string
 	GameFinishedAt      time.Time
-	IsCs2               bool
 	MapName             string
 	MatchResult         string
 	RankType            int
@@ -150,25 +241,20 @@ type MatchResult struct {
 	GameMode  string
 }
 
-func parseGameResponseFromLeetify(game leetify.LeetifyGameResponse) MatchResult {
-	gameTime, _ := time.Parse(time.RFC3339, game.GameFinishedAt)
-
-	mode := "unknown"
-	if game.DataSource == "matchmaking_competitive" {
-		mode = "Competitive"
-	} else if game.DataSource == "matchmaking" {
-		mode = "Premier"
-	} else if game.DataSource == "faceit" {
-		mode = "Faceit"
+func parseMatch(game provider.Match) MatchResult {
+	mode := game.GameMode
+	if len(mode) == 0 {
+		mode = "unknown"
 	}
 
 	match := MatchResult{
-		GameID:              game.GameId,
+		GameID:              game.GameID,
+		Provider:            game.Provider,
+		URL:                 game.URL,
 		OwnTeamSteam64Ids:   game.OwnTeamSteam64Ids,
 		EnemyTeamSteam64Ids: game.EnemyTeamSteam64Ids,
 		DataSource:          game.DataSource,
-		GameFinishedAt:      gameTime,
-		IsCs2:               game.IsCs2,
+		GameFinishedAt:      game.GameFinishedAt,
 		MapName:             game.MapName,
 		MatchResult:         game.MatchResult,
 		RankType:            game.RankType,
@@ -177,7 +263,7 @@ func parseGameResponseFromLeetify(game leetify.LeetifyGameResponse) MatchResult
 		GameMode: mode,
 	}
 
-	// Create team structures based on Leetify's own/enemy team distinction
+	// Create team structures based on the own/enemy team distinction
 	var ownTeamPlayers []Player
 	for _, steamID := range game.OwnTeamSteam64Ids {
 		ownTeamPlayers = append(ownTeamPlayers, Player{
@@ -192,23 +278,31 @@ func parseGameResponseFromLeetify(game leetify.LeetifyGameResponse) MatchResult
 		})
 	}
 
-	// Determine winner based on match result from Leetify
+	// Determine winner based on match result from the provider.
+	// Scores stay 0 when the source does not know them yet.
 	var ownTeamScore, enemyTeamScore int
+	hasScores := len(game.Scores) == 2
 	switch game.MatchResult {
 	case "win":
 		match.Winner = 1 // Own team won
 		// Own team has higher score, enemy team has lower score
-		ownTeamScore = slices.Max(game.Scores)
-		enemyTeamScore = slices.Min(game.Scores)
+		if hasScores {
+			ownTeamScore = slices.Max(game.Scores)
+			enemyTeamScore = slices.Min(game.Scores)
+		}
 	case "loss":
 		match.Winner = 2 // Enemy team won
 		// Enemy team has higher score, own team has lower score
-		enemyTeamScore = slices.Max(game.Scores)
-		ownTeamScore = slices.Min(game.Scores)
+		if hasScores {
+			enemyTeamScore = slices.Max(game.Scores)
+			ownTeamScore = slices.Min(game.Scores)
+		}
 	default:
 		match.Winner = 0 // tie or unknown
 		// Assign in array order since scores are equal or unknown
-		ownTeamScore, enemyTeamScore = game.Scores[0], game.Scores[1]
+		if hasScores {
+			ownTeamScore, enemyTeamScore = game.Scores[0], game.Scores[1]
+		}
 	}
 
 	match.OwnTeam = Team{
@@ -224,15 +318,17 @@ func parseGameResponseFromLeetify(game leetify.LeetifyGameResponse) MatchResult
 }
 
 func ParseMatchResultWithDetails(
-	game leetify.LeetifyGameResponse,
-	matchDetails *leetify.MatchDetailsResponse,
+	game provider.Match,
+	matchDetails *provider.MatchDetails,
 	steamPlayers []steam.SteamPlayer,
 	players []config.Player,
 ) MatchWithDetails {
-	match := parseGameResponseFromLeetify(game)
+	match := parseMatch(completeMatch(game, matchDetails))
 
 	matchWithDetails := MatchWithDetails{
 		GameID:         match.GameID,
+		Provider:       match.Provider,
+		MatchURL:       match.URL,
 		GameMode:       match.GameMode,
 		GameFinishedAt: match.GameFinishedAt,
 		MapName:        match.MapName,
@@ -246,7 +342,8 @@ func ParseMatchResultWithDetails(
 			Players:      parsePlayers(match.EnemyTeam.Players, matchDetails, steamPlayers, []config.Player{}),
 			KnownPlayers: []Player{},
 		},
-		Winner: match.Winner,
+		Winner:    match.Winner,
+		ShareCode: parseShareCode(matchDetails),
 	}
 
 	ownTeamKnownPlayers := parseKnownPlayers(matchWithDetails.OwnTeam.Players, players)
@@ -255,6 +352,37 @@ func ParseMatchResultWithDetails(
 	return matchWithDetails
 }
 
+// parseShareCode returns the share code of the match if it is valid
+func parseShareCode(matchDetails *provider.MatchDetails) string {
+	if matchDetails == nil || len(matchDetails.ShareCode) == 0 {
+		return ""
+	}
+
+	if !sharecode.Valid(matchDetails.ShareCode) {
+		log.Printf("Parser: Ignoring invalid share code %q of match %s", matchDetails.ShareCode, matchDetails.GameID)
+		return ""
+	}
+
+	return matchDetails.ShareCode
+}
+
+// completeMatch fills the map and scores missing from the list of matches
+// with the ones of the match details
+func completeMatch(game provider.Match, matchDetails *provider.MatchDetails) provider.Match {
+	if matchDetails == nil {
+		return game
+	}
+
+	if len(game.MapName) == 0 {
+		game.MapName = matchDetails.MapName
+	}
+	if len(matchDetails.Scores) == 2 {
+		game.Scores = matchDetails.Scores
+	}
+
+	return game
+}
+
 func parseKnownPlayers(players []Player, configPlayers []config.Player) []Player {
 	var knownPlayers []Player
 
@@ -277,7 +405,7 @@ func parseKnownPlayers(players []Player, configPlayers []config.Player) []Player
 
 func parsePlayers(
 	players []Player,
-	matchDetails *leetify.MatchDetailsResponse,
+	matchDetails *provider.MatchDetails,
 	steamPlayers []steam.SteamPlayer,
 	configPlayers []config.Player,
 ) []Player {
@@ -299,25 +427,39 @@ func parsePlayers(
 		// Update with match details data if available
 		if matchDetails != nil {
 			// Find the player stats and update
-			for _, p := range matchDetails.PlayerStats {
+			for _, p := range matchDetails.Players {
 				name := updatedPlayer.Name
 				if len(name) == 0 {
 					name = p.Name
 				}
 
-				if p.Steam64ID == updatedPlayer.SteamID {
-					updatedPlayer.Kills = p.TotalKills
-					updatedPlayer.Deaths = p.TotalDeaths
+				if p.SteamID == updatedPlayer.SteamID {
+					updatedPlayer.Kills = p.Kills
+					updatedPlayer.Deaths = p.Deaths
 					updatedPlayer.Mvps = p.Mvps
 					updatedPlayer.KdRatio = p.KdRatio
 					updatedPlayer.TotalDamage = p.TotalDamage
+					updatedPlayer.Assists = p.Assists
+					updatedPlayer.HeadshotKills = p.HeadshotKills
+					updatedPlayer.AvgDamage = p.ADR
+					updatedPlayer.LeetifyRating = p.LeetifyRating
+					updatedPlayer.HltvRating = p.HltvRating
+					updatedPlayer.DoubleKills = p.DoubleKills
+					updatedPlayer.TripleKills = p.TripleKills
+					updatedPlayer.QuadKills = p.QuadKills
+					updatedPlayer.Aces = p.Aces
+					updatedPlayer.ClutchesWon = p.ClutchesWon
+					updatedPlayer.FlashAssists = p.FlashAssists
+					updatedPlayer.UtilityDamage = p.UtilityDamage
+					updatedPlayer.TradeKills = p.TradeKills
+					updatedPlayer.RoundsPlayed = p.RoundsPlayed
 					updatedPlayer.Name = name
 					break
 				}
 			}
 
 			// Find the player rank and update
-			for _, p := range matchDetails.MatchmakingGameStats {
+			for _, p := range matchDetails.Ranks {
 				if p.SteamID == updatedPlayer.SteamID {
 					updatedPlayer.RankStats = PlayerRankStats{
 						Rank:        p.Rank,
@@ -325,6 +467,7 @@ func parsePlayers(
 						RankType:    p.RankType,
 						RankChanged: p.RankChanged,
 						Wins:        p.Wins,
+						Level:       p.Level,
 					}
 					break
 				}
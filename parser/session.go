@@ -91,6 +91,7 @@ func (s *SessionWithDetails) KnownPlayersWithCumulatedStats() []Player {
 						playerSession.Kills += p.Kills
 						playerSession.Deaths += p.Deaths
 						playerSession.TotalDamage += p.TotalDamage
+						playerSession.addDetailedStats(p)
 
 						// Only update if rank is Premier
 						if p.RankStats.RankType == 11 {
@@ -108,6 +109,7 @@ func (s *SessionWithDetails) KnownPlayersWithCumulatedStats() []Player {
 							Deaths:      p.Deaths,
 							TotalDamage: p.TotalDamage,
 						}
+						playerForSession.addDetailedStats(p)
 						if p.RankStats.RankType == 11 {
 							playerForSession.RankStats = PlayerRankStats{
 								Rank:     p.RankStats.Rank,
@@ -134,6 +136,12 @@ func (s *SessionWithDetails) KnownPlayersWithCumulatedStats() []Player {
 			finalPlayer.KdRatio = float64(finalPlayer.Kills)
 		}
 
+		// Ratings are averaged over the rounds they were computed on
+		if finalPlayer.RoundsPlayed > 0 {
+			finalPlayer.LeetifyRating /= float64(finalPlayer.RoundsPlayed)
+			finalPlayer.HltvRating /= float64(finalPlayer.RoundsPlayed)
+		}
+
 		// The rank has changed if the final rank is different from the very first OldRank
 		finalPlayer.RankStats.RankChanged = (finalPlayer.RankStats.Rank != session.RankStats.OldRank)
 
@@ -148,6 +156,24 @@ func (s *SessionWithDetails) KnownPlayersWithCumulatedStats() []Player {
 	return uniquePlayers
 }
 
+// addDetailedStats adds the detailed stats of a match to the session totals.
+// Ratings are summed weighted by rounds and averaged once all matches are added.
+func (p *Player) addDetailedStats(match Player) {
+	p.Assists += match.Assists
+	p.HeadshotKills += match.HeadshotKills
+	p.DoubleKills += match.DoubleKills
+	p.TripleKills += match.TripleKills
+	p.QuadKills += match.QuadKills
+	p.Aces += match.Aces
+	p.ClutchesWon += match.ClutchesWon
+	p.FlashAssists += match.FlashAssists
+	p.UtilityDamage += match.UtilityDamage
+	p.TradeKills += match.TradeKills
+	p.RoundsPlayed += match.RoundsPlayed
+	p.LeetifyRating += match.LeetifyRating * float64(match.RoundsPlayed)
+	p.HltvRating += match.HltvRating * float64(match.RoundsPlayed)
+}
+
 func (s *SessionWithDetails) KnownPlayersSortedByKills() []Player {
 	players := s.KnownPlayersWithCumulatedStats()
 	sort.Slice(players, func(i, j int) bool {
@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"time"
+)
+
+// Rank types of a match, as reported by Leetify
+const (
+	RankTypePremier     = 11
+	RankTypeCompetitive = 12
+	// RankTypeFaceit marks FACEIT ELO, it is not a Leetify rank type
+	RankTypeFaceit = 100
+)
+
+// Match is a finished match as listed by a MatchSource.
+// JSON field names match the Leetify profile games so previously
+// persisted matches can still be decoded.
+type Match struct {
+	Provider            string    `json:"provider"`
+	GameID              string    `json:"gameId"`
+	GameFinishedAt      time.Time `json:"gameFinishedAt"`
+	GameMode            string    `json:"gameMode"`
+	DataSource          string    `json:"dataSource"`
+	MapName             string    `json:"mapName"`
+	MatchResult         string    `json:"matchResult"` // "win", "loss" or "tie"
+	RankType            int       `json:"rankType"`
+	Scores              []int     `json:"scores"`
+	OwnTeamSteam64Ids   []string  `json:"ownTeamSteam64Ids"`
+	EnemyTeamSteam64Ids []string  `json:"enemyTeamSteam64Ids"`
+	// URL of the match page on the provider website
+	URL string `json:"url"`
+}
+
+// MatchDetails are the per-player statistics of a match
+type MatchDetails struct {
+	GameID     string
+	MapName    string
+	ShareCode  string
+	TeamScores []int
+	// Scores of the own team then the enemy team, set by sources that
+	// cannot list them with the match, such as FACEIT
+	Scores  []int
+	Players []PlayerStats
+	Ranks   []RankStats
+}
+
+// IsComplete reports whether the source has finished processing the match:
+// player statistics are known, and ranks too for Premier matches
+func (d *MatchDetails) IsComplete(match Match) bool {
+	if d == nil || len(d.Players) == 0 {
+		return false
+	}
+
+	if match.GameMode == "Premier" && len(d.Ranks) == 0 {
+		return false
+	}
+
+	return true
+}
+
+type PlayerStats struct {
+	SteamID           string
+	Name              string
+	InitialTeamNumber int
+	Score             int
+	Mvps              int
+	Kills             int
+	Deaths            int
+	KdRatio           float64
+	TotalDamage       int
+	Assists           int
+	HeadshotKills     int
+	// ADR is the average damage per round
+	ADR float64
+	// LeetifyRating is the Leetify rating, 0 when unknown
+	LeetifyRating float64
+	// HltvRating is the HLTV 1.0 style rating, 0 when unknown
+	HltvRating    float64
+	DoubleKills   int
+	TripleKills   int
+	QuadKills     int
+	Aces          int
+	ClutchesWon   int
+	FlashAssists  int
+	UtilityDamage int
+	TradeKills    int
+	RoundsPlayed  int
+}
+
+// RankStats is the rank of a player after the match
+type RankStats struct {
+	SteamID     string
+	Rank        int
+	OldRank     int
+	RankType    int
+	RankChanged bool
+	Wins        int
+	// Level is the FACEIT skill level, from 1 to 10
+	Level int
+}
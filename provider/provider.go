@@ -0,0 +1,50 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mxdc/cs2-discord-bot/config"
+)
+
+// MatchSource is a provider of CS2 matches, such as Leetify.
+// It lists the recent matches of a player and fetches their details.
+type MatchSource interface {
+	// Name identifies the source, it is stored in the Provider field of its matches
+	Name() string
+	RecentMatches(ctx context.Context, player config.Player) ([]Match, error)
+	MatchDetails(ctx context.Context, match Match) (*MatchDetails, error)
+}
+
+// Registry holds the configured sources and dispatches match details
+// requests to the source a match comes from
+type Registry struct {
+	sources []MatchSource
+}
+
+func NewRegistry(sources ...MatchSource) *Registry {
+	return &Registry{sources: sources}
+}
+
+func (r *Registry) Sources() []MatchSource {
+	return r.sources
+}
+
+func (r *Registry) Get(name string) (MatchSource, bool) {
+	for _, source := range r.sources {
+		if source.Name() == name {
+			return source, true
+		}
+	}
+
+	return nil, false
+}
+
+func (r *Registry) MatchDetails(ctx context.Context, match Match) (*MatchDetails, error) {
+	source, found := r.Get(match.Provider)
+	if !found {
+		return nil, fmt.Errorf("unknown match provider: %q", match.Provider)
+	}
+
+	return source.MatchDetails(ctx, match)
+}
@@ -0,0 +1,81 @@
+package session
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/banwatch"
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/discord"
+	"github.com/mxdc/cs2-discord-bot/steam"
+)
+
+// BanNotifier periodically checks the bans of the opponents recorded by the
+// notifiers and announces the new ones
+type BanNotifier struct {
+	settings *LiveSettings
+	bans     *banwatch.Watcher
+	clock    clock.Clock
+	interval time.Duration
+}
+
+func NewBanNotifier(
+	settings *LiveSettings,
+	bans *banwatch.Watcher,
+	clk clock.Clock,
+	interval time.Duration,
+) *BanNotifier {
+	return &BanNotifier{
+		settings: settings,
+		bans:     bans,
+		clock:    clk,
+		interval: interval,
+	}
+}
+
+// Run checks the bans every interval until the context is done
+func (bn *BanNotifier) Run(ctx context.Context) {
+	log.Printf("BanWatch: Started, checking opponent bans every %s", bn.interval)
+
+	ticker := bn.clock.NewTicker(bn.interval)
+	defer ticker.Stop()
+
+	for {
+		bn.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("BanWatch: Stopped")
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+func (bn *BanNotifier) check(ctx context.Context) {
+	settings := bn.settings.Load()
+	steamClient := steam.NewSteamClient(settings.Config.SteamAPIKey, settings.Config.SteamAPIURL)
+
+	bans, err := bn.bans.Check(ctx, steamClient)
+	if err != nil {
+		// Bans found in the batches that succeeded are still announced
+		log.Printf("BanWatch: Warning: failed to check bans: %v", err)
+	}
+	if len(bans) == 0 {
+		return
+	}
+
+	log.Printf("BanWatch: %d new bans found", len(bans))
+	discordClient := discord.NewWebhookClient(settings.Config.DiscordHook, nil, settings.Translations, false)
+	for _, ban := range bans {
+		// Unacknowledged bans are reported again by the next check
+		if err := discordClient.SendBanAlert(ctx, ban); err != nil {
+			log.Printf("Discord: Error sending ban alert: %v", err)
+			continue
+		}
+		if err := bn.bans.Acknowledge(ban); err != nil {
+			log.Printf("BanWatch: Warning: failed to save ban state: %v", err)
+		}
+	}
+}
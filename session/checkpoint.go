@@ -0,0 +1,204 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/leetify"
+	"github.com/mxdc/cs2-discord-bot/provider"
+	"github.com/mxdc/cs2-discord-bot/storage"
+)
+
+// Version 1 checkpoints hold Leetify games, version 2 provider matches and
+// version 3 adds the flushed sessions not notified yet
+const sessionCheckpointVersion = 3
+
+type sessionCheckpointFile struct {
+	Version           int                  `json:"version"`
+	Matches           json.RawMessage      `json:"matches"`
+	LastMatchEndTime  time.Time            `json:"lastMatchEndTime"`
+	LastDetectionTime time.Time            `json:"lastDetectionTime"`
+	Pending           []*checkpointSession `json:"pending,omitempty"`
+}
+
+// checkpointSession is a copy of a session, safe to write while the
+// session manager keeps adding matches to the open one
+type checkpointSession struct {
+	Matches           []provider.Match `json:"matches"`
+	LastMatchEndTime  time.Time        `json:"lastMatchEndTime"`
+	LastDetectionTime time.Time        `json:"lastDetectionTime"`
+	IsFresh           bool             `json:"isFresh"`
+}
+
+// SessionCheckpoint persists the open session so it can be resumed after a
+// restart, along with the flushed sessions until they have been notified.
+// A nil checkpoint disables persistence.
+type SessionCheckpoint struct {
+	path string
+
+	mu      sync.Mutex
+	open    *checkpointSession
+	pending []*checkpointSession
+}
+
+func NewSessionCheckpoint(path string) *SessionCheckpoint {
+	return &SessionCheckpoint{path: path}
+}
+
+// Save writes the open session to disk, replacing any previous one
+func (c *SessionCheckpoint) Save(s *GameSession) error {
+	if c == nil || s == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.open = newCheckpointSession(s)
+	return c.write()
+}
+
+// Flush moves the open session to the pending ones. It stays in the
+// checkpoint until the session is acknowledged once notified.
+func (c *SessionCheckpoint) Flush(s *GameSession) error {
+	if c == nil || s == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := newCheckpointSession(s)
+	c.pending = append(c.pending, p)
+	s.ack = c.acknowledger(p)
+	c.open = nil
+
+	return c.write()
+}
+
+// Load returns the checkpointed open session, or nil when there is none,
+// and the flushed sessions that were not notified before the restart
+func (c *SessionCheckpoint) Load(debugMode bool) (*GameSession, []*GameSession, error) {
+	if c == nil {
+		return nil, nil, nil
+	}
+
+	var file sessionCheckpointFile
+	err := storage.ReadJSON(c.path, &file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matches, err := decodeCheckpointMatches(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid session checkpoint %s: %w", c.path, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var pending []*GameSession
+	for _, p := range file.Pending {
+		s := newGameSession(debugMode)
+		s.Matches = p.Matches
+		s.LastMatchEndTime = p.LastMatchEndTime
+		s.LastDetectionTime = p.LastDetectionTime
+		s.IsFresh = p.IsFresh
+		s.ack = c.acknowledger(p)
+		pending = append(pending, s)
+	}
+	c.pending = file.Pending
+
+	if len(matches) == 0 {
+		return nil, pending, nil
+	}
+
+	s := newGameSession(debugMode)
+	s.Matches = matches
+	s.LastMatchEndTime = file.LastMatchEndTime
+	s.LastDetectionTime = file.LastDetectionTime
+	c.open = newCheckpointSession(s)
+
+	return s, pending, nil
+}
+
+func newCheckpointSession(s *GameSession) *checkpointSession {
+	return &checkpointSession{
+		Matches:           slices.Clone(s.Matches),
+		LastMatchEndTime:  s.LastMatchEndTime,
+		LastDetectionTime: s.LastDetectionTime,
+		IsFresh:           s.IsFresh,
+	}
+}
+
+// acknowledger returns the function removing the pending session once notified
+func (c *SessionCheckpoint) acknowledger(p *checkpointSession) func() error {
+	return func() error {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.pending = slices.DeleteFunc(c.pending, func(other *checkpointSession) bool { return other == p })
+		return c.write()
+	}
+}
+
+// write saves the open and pending sessions, the file is removed when
+// there are none
+func (c *SessionCheckpoint) write() error {
+	if c.open == nil && len(c.pending) == 0 {
+		err := os.Remove(c.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	file := sessionCheckpointFile{
+		Version: sessionCheckpointVersion,
+		Matches: json.RawMessage("[]"),
+		Pending: c.pending,
+	}
+	if c.open != nil {
+		matches, err := json.Marshal(c.open.Matches)
+		if err != nil {
+			return err
+		}
+		file.Matches = matches
+		file.LastMatchEndTime = c.open.LastMatchEndTime
+		file.LastDetectionTime = c.open.LastDetectionTime
+	}
+
+	return storage.WriteJSON(c.path, file)
+}
+
+func decodeCheckpointMatches(file sessionCheckpointFile) ([]provider.Match, error) {
+	switch file.Version {
+	case 1:
+		var games []leetify.LeetifyGameResponse
+		if err := json.Unmarshal(file.Matches, &games); err != nil {
+			return nil, err
+		}
+
+		matches := make([]provider.Match, 0, len(games))
+		for _, game := range games {
+			matches = append(matches, leetify.ToMatch(game))
+		}
+		return matches, nil
+
+	case 2, sessionCheckpointVersion:
+		var matches []provider.Match
+		err := json.Unmarshal(file.Matches, &matches)
+		return matches, err
+
+	default:
+		return nil, fmt.Errorf("unsupported version %d", file.Version)
+	}
+}
@@ -1,15 +1,20 @@
 package session
 
 import (
+	"context"
 	"log"
 	"time"
+
+	"github.com/mxdc/cs2-discord-bot/clock"
 )
 
 type SessionManager struct {
-	in        <-chan MatchDetected
-	out       chan<- GameSession
-	seenGames *SeenGames
-	debugMode bool
+	in         <-chan MatchDetected
+	out        chan<- GameSession
+	seenGames  GameStore
+	checkpoint *SessionCheckpoint
+	clock      clock.Clock
+	debugMode  bool
 }
 
 const (
@@ -19,90 +24,164 @@ const (
 func NewSessionManager(
 	in <-chan MatchDetected,
 	out chan<- GameSession,
+	seenGames GameStore,
+	checkpoint *SessionCheckpoint,
+	clk clock.Clock,
 	debugMode bool,
 ) *SessionManager {
-	seenGames := &SeenGames{games: []SeenGame{}}
-
 	return &SessionManager{
-		in:        in,
-		out:       out,
-		seenGames: seenGames,
-		debugMode: debugMode,
+		in:         in,
+		out:        out,
+		seenGames:  seenGames,
+		checkpoint: checkpoint,
+		clock:      clk,
+		debugMode:  debugMode,
 	}
 }
 
-func (sm *SessionManager) HandleIncomingMatches() {
-	var currentSession *GameSession
-	ticker := time.NewTicker(tickerInterval)
+// HandleIncomingMatches groups incoming matches into sessions until the input
+// channel is closed, then closes the output channel
+func (sm *SessionManager) HandleIncomingMatches(ctx context.Context) {
+	currentSession, pending := sm.restoreSession()
+	ticker := sm.clock.NewTicker(tickerInterval)
 	defer ticker.Stop()
+	defer close(sm.out)
+
+	// Sessions flushed but not notified before the restart are sent again
+	for _, s := range pending {
+		select {
+		case sm.out <- *s:
+			log.Printf("SessionManager: Resending session with %d matches not notified before the restart", len(s.Matches))
+		case <-ctx.Done():
+			return
+		}
+	}
 
 	log.Println("SessionManager: Started, waiting for matches...")
 
 	for {
 		select {
 
-		case msg := <-sm.in:
+		case msg, ok := <-sm.in:
+			if !ok {
+				sm.stop(ctx, currentSession)
+				return
+			}
+
 			// drop old matches
-			if msg.IsTooOld() {
-				log.Printf("SessionManager: Match %s is too old, ignoring", msg.Match.GameId)
+			if msg.IsTooOld(sm.clock.Now()) {
+				log.Printf("SessionManager: Match %s is too old, ignoring", msg.Match.GameID)
 				continue
 			}
 
 			if !sm.seenGames.ShouldNotify(msg.Player.SteamID, msg.Match) {
-				log.Printf("SessionManager: Match %s has already been seen, ignoring", msg.Match.GameId)
+				log.Printf("SessionManager: Match %s has already been seen, ignoring", msg.Match.GameID)
 				continue
 			}
 
-			sm.seenGames.AddGame(msg.Player.SteamID, msg.Match.GameId, msg.Match.GameFinishedAt)
+			sm.seenGames.AddGame(msg.Player.SteamID, msg.Match)
 
-			log.Printf("SessionManager: New match detected: %s", msg.Match.GameId)
+			log.Printf("SessionManager: New match detected: %s", msg.Match.GameID)
 
 			if currentSession == nil {
 				currentSession = NewSession(msg.Match, msg.DetectedAt, sm.debugMode)
-				log.Printf("SessionManager: Started new session with match %s", msg.Match.GameId)
+				sm.saveCheckpoint(currentSession)
+				log.Printf("SessionManager: Started new session with match %s", msg.Match.GameID)
 				continue
 			}
 
 			if currentSession.IsMatchPartOfSession(msg.Match) {
 				currentSession.AddMatch(msg.Match, msg.DetectedAt)
-				log.Printf("SessionManager: Added match %s to current session", msg.Match.GameId)
+				sm.saveCheckpoint(currentSession)
+				log.Printf("SessionManager: Added match %s to current session", msg.Match.GameID)
 				continue
 			}
 
 			if currentSession.IsMatchBeforeCurrentSession(msg.Match) {
-				log.Printf("SessionManager: Match %s is before current session, ignoring", msg.Match.GameId)
+				log.Printf("SessionManager: Match %s is before current session, ignoring", msg.Match.GameID)
 				continue
 			}
 
 			log.Printf("SessionManager: Match too far in time, flushing session")
-			sm.flush(currentSession)
+			sm.flush(ctx, currentSession)
 
 			currentSession = NewSession(msg.Match, msg.DetectedAt, sm.debugMode)
-			log.Printf("SessionManager: Started new session with match %s", msg.Match.GameId)
+			sm.saveCheckpoint(currentSession)
+			log.Printf("SessionManager: Started new session with match %s", msg.Match.GameID)
 
-		case <-ticker.C:
+		case <-ticker.C():
 			if currentSession == nil {
 				continue
 			}
 
-			if currentSession.IsSessionTimeout() {
+			if currentSession.IsSessionTimeout(sm.clock.Now()) {
 				log.Printf("SessionManager: Inactivity timeout reached, flushing session")
-				sm.flush(currentSession)
+				sm.flush(ctx, currentSession)
 				currentSession = nil
 			}
 		}
 	}
 }
 
-func (sm *SessionManager) flush(currentSession *GameSession) {
+// restoreSession resumes the session checkpointed before a restart, if any,
+// and returns the flushed sessions that were not notified
+func (sm *SessionManager) restoreSession() (*GameSession, []*GameSession) {
+	restored, pending, err := sm.checkpoint.Load(sm.debugMode)
+	if err != nil {
+		log.Printf("SessionManager: Error restoring session checkpoint: %v", err)
+		return nil, nil
+	}
+
+	if restored != nil {
+		log.Printf("SessionManager: Restored session with %d matches", len(restored.Matches))
+	}
+
+	return restored, pending
+}
+
+// stop keeps the open session checkpointed for the next run,
+// or flushes it when there is no checkpoint to resume from
+func (sm *SessionManager) stop(ctx context.Context, currentSession *GameSession) {
+	if currentSession == nil {
+		log.Println("SessionManager: Stopped")
+		return
+	}
+
+	if sm.checkpoint != nil {
+		sm.saveCheckpoint(currentSession)
+		log.Printf("SessionManager: Stopped, open session with %d matches kept for next run", len(currentSession.Matches))
+		return
+	}
+
+	log.Printf("SessionManager: Stopped, flushing open session")
+	sm.flush(ctx, currentSession)
+}
+
+func (sm *SessionManager) flush(ctx context.Context, currentSession *GameSession) {
 	if currentSession == nil {
 		return
 	}
 
 	last := currentSession.LastMatch()
 	recent := sm.seenGames.MostRecentGame()
-	if len(last.GameId) > 0 && len(recent.GameID) > 0 && last.GameId == recent.GameID {
+	if len(last.GameID) > 0 && len(recent.GameID) > 0 && last.GameID == recent.GameID {
 		currentSession.IsFresh = true
 	}
-	sm.out <- *currentSession
+
+	// The session stays checkpointed until the notifier acknowledges it
+	if err := sm.checkpoint.Flush(currentSession); err != nil {
+		log.Printf("SessionManager: Error saving session checkpoint: %v", err)
+	}
+
+	select {
+	case sm.out <- *currentSession:
+	case <-ctx.Done():
+		log.Printf("SessionManager: Session not flushed: %v", ctx.Err())
+	}
+}
+
+func (sm *SessionManager) saveCheckpoint(currentSession *GameSession) {
+	if err := sm.checkpoint.Save(currentSession); err != nil {
+		log.Printf("SessionManager: Error saving session checkpoint: %v", err)
+	}
 }
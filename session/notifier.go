@@ -1,163 +1,355 @@
 package session
 
 import (
+	"context"
 	"log"
+	"sync"
 	"time"
 
+	"github.com/mxdc/cs2-discord-bot/banwatch"
+	"github.com/mxdc/cs2-discord-bot/clock"
 	"github.com/mxdc/cs2-discord-bot/config"
 	"github.com/mxdc/cs2-discord-bot/discord"
-	"github.com/mxdc/cs2-discord-bot/leetify"
-	"github.com/mxdc/cs2-discord-bot/locales"
+	"github.com/mxdc/cs2-discord-bot/history"
 	"github.com/mxdc/cs2-discord-bot/mistral"
 	"github.com/mxdc/cs2-discord-bot/parser"
+	"github.com/mxdc/cs2-discord-bot/provider"
 	"github.com/mxdc/cs2-discord-bot/steam"
+	"github.com/mxdc/cs2-discord-bot/suspects"
 )
 
 type MatchDetected struct {
-	Match      leetify.LeetifyGameResponse
+	Match      provider.Match
 	Player     config.Player
 	DetectedAt time.Time
 }
 
-func (md *MatchDetected) IsTooOld() bool {
-	matchEndTime, _ := time.Parse(time.RFC3339, md.Match.GameFinishedAt)
-	return time.Since(matchEndTime) > 24*time.Hour
+func (md *MatchDetected) IsTooOld(now time.Time) bool {
+	return now.Sub(md.Match.GameFinishedAt) > 24*time.Hour
 }
 
+const (
+	// detailsPollInterval is the delay between two match details requests
+	detailsPollInterval = 1 * time.Minute
+	// detailsDeadline is how long a match waits for complete details
+	// before a provisional message is sent
+	detailsDeadline = 10 * time.Minute
+	// detailsEditDeadline is how long a provisional message keeps waiting
+	// for complete details to be edited
+	detailsEditDeadline = 2 * time.Hour
+)
+
 type MatchNotifier struct {
-	cfg           *config.AppConfig
-	client        *leetify.LeetifyClient
+	settings      *LiveSettings
+	sources       *provider.Registry
 	mistralClient *mistral.MistralClient
-	translations  locales.Translations
+	seenGames     GameStore
+	history       *history.Store
+	personas      *steam.PersonaCache
+	bans          *banwatch.Watcher
+	suspects      *suspects.Detector
+	clock         clock.Clock
 	in            <-chan MatchDetected
+	withRank      bool
+
+	mu sync.Mutex
+	// notifying holds the games being notified, they are recorded as seen once posted
+	notifying map[string]bool
 }
 
 func NewMatchNotifier(
-	cfg *config.AppConfig,
-	client *leetify.LeetifyClient,
+	settings *LiveSettings,
+	sources *provider.Registry,
 	mistralClient *mistral.MistralClient,
-	translations locales.Translations,
+	seenGames GameStore,
+	matchHistory *history.Store,
+	personas *steam.PersonaCache,
+	bans *banwatch.Watcher,
+	detector *suspects.Detector,
+	clk clock.Clock,
 	in <-chan MatchDetected,
+	withRank bool,
 ) *MatchNotifier {
 	return &MatchNotifier{
-		cfg:           cfg,
-		client:        client,
+		settings:      settings,
+		sources:       sources,
 		mistralClient: mistralClient,
-		translations:  translations,
+		seenGames:     seenGames,
+		history:       matchHistory,
+		personas:      personas,
+		bans:          bans,
+		suspects:      detector,
+		clock:         clk,
 		in:            in,
+		withRank:      withRank,
+		notifying:     map[string]bool{},
 	}
 }
 
-func (mm *MatchNotifier) HandleMatch() {
+// HandleMatch notifies every incoming match until the input channel is closed.
+// Matches are notified concurrently so waiting for the details of one match
+// does not delay the others. Once the input is closed, pending matches stop
+// waiting for their details and are sent with what is known so far.
+func (mm *MatchNotifier) HandleMatch(ctx context.Context) {
 	log.Println("Notifier: Started notifier, waiting for matches...")
-	seenGames := &SeenGames{games: []SeenGame{}}
-	discordClient := discord.NewWebhookClient(mm.cfg.DiscordHook, mm.mistralClient, mm.translations, false)
-	steamClient := steam.NewSteamClient(mm.cfg.SteamAPIKey)
 
+	waitCtx, stopWaiting := context.WithCancel(ctx)
+	defer stopWaiting()
+
+	var pending sync.WaitGroup
 	for msg := range mm.in {
-		if !seenGames.ShouldNotify(msg.Player.SteamID, msg.Match) {
+		// drop old matches
+		if msg.IsTooOld(mm.clock.Now()) {
+			log.Printf("Manager: Match %s is too old, ignoring", msg.Match.GameID)
 			continue
 		}
 
-		seenGames.AddGame(msg.Player.SteamID, msg.Match.GameId, msg.Match.GameFinishedAt)
-		log.Println("Manager: New match detected:", msg.Match.GameId)
+		if !mm.seenGames.ShouldNotify(msg.Player.SteamID, msg.Match) || !mm.startNotifying(msg.Match.GameID) {
+			continue
+		}
 
-		// Get all Steam IDs from both teams
-		allSteamIDs := append(msg.Match.OwnTeamSteam64Ids, msg.Match.EnemyTeamSteam64Ids...)
+		log.Println("Manager: New match detected:", msg.Match.GameID)
 
-		// Get Steam player data (names and countries)
-		steamPlayers, err := steamClient.GetSteamPlayers(allSteamIDs)
-		if err != nil {
-			// Continue without steam data
-			log.Printf("Manager: Warning: failed to get steam players: %v", err)
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
+			defer mm.doneNotifying(msg.Match.GameID)
+
+			// The game is only recorded once announced, so a match dropped
+			// on shutdown is detected again after the restart
+			if mm.notify(ctx, waitCtx, msg) {
+				mm.seenGames.AddGame(msg.Player.SteamID, msg.Match)
+			}
+		}()
+	}
+
+	stopWaiting()
+	pending.Wait()
+	log.Println("Notifier: Stopped")
+}
+
+// startNotifying reports whether the game is not already being notified,
+// the same match is detected by the crawler of every tracked player in it
+func (mm *MatchNotifier) startNotifying(gameID string) bool {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if mm.notifying[gameID] {
+		return false
+	}
+	mm.notifying[gameID] = true
+	return true
+}
+
+func (mm *MatchNotifier) doneNotifying(gameID string) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	delete(mm.notifying, gameID)
+}
+
+// notify sends the match once its details are complete. When they are still
+// incomplete after detailsDeadline, or when waitCtx is done, a provisional
+// message is sent and edited once the source has finished processing the
+// match. It reports whether the match was posted to Discord.
+func (mm *MatchNotifier) notify(ctx, waitCtx context.Context, msg MatchDetected) bool {
+	settings := mm.settings.Load()
+	discordClient := discord.NewWebhookClient(settings.Config.DiscordHook, mm.mistralClient, settings.Translations, mm.withRank)
+	steamClient := steam.NewSteamClient(settings.Config.SteamAPIKey, settings.Config.SteamAPIURL)
+
+	// Get all Steam IDs from both teams
+	allSteamIDs := append(msg.Match.OwnTeamSteam64Ids, msg.Match.EnemyTeamSteam64Ids...)
+
+	// Get Steam player data (names and countries)
+	steamPlayers, err := mm.personas.Players(ctx, steamClient, allSteamIDs)
+	if err != nil {
+		// Continue without steam data
+		log.Printf("Manager: Warning: failed to get steam players: %v", err)
+	}
+
+	// Steam profiles of the opponents, used to flag suspicious ones once their stats are known
+	profiles, err := mm.suspects.Profiles(ctx, steamClient, steamPlayers, msg.Match.EnemyTeamSteam64Ids)
+	if err != nil {
+		log.Printf("Manager: Warning: failed to get opponent profiles: %v", err)
+	}
+
+	matchDetails, ready := mm.waitForDetails(ctx, waitCtx, msg.Match, detailsDeadline)
+	if ctx.Err() != nil {
+		log.Printf("Manager: Match %s dropped: %v", msg.Match.GameID, ctx.Err())
+		return false
+	}
+	matchWithDetails := parser.ParseMatchResultWithDetails(msg.Match, matchDetails, steamPlayers, settings.Config.Players)
+	matchWithDetails.Suspects = mm.suspects.Flag(matchWithDetails.EnemyTeam, profiles)
+
+	if ready {
+		mm.saveHistory(matchWithDetails)
+		if err := discordClient.SendMatchResult(ctx, matchWithDetails); err != nil {
+			log.Printf("Discord: Error sending Discord webhook: %v", err)
+			return false
 		}
+		return true
+	}
 
-		time.Sleep(5 * time.Minute)
-		matchDetails, err := mm.client.GetMatchDetails(msg.Match.GameId)
+	if waitCtx.Err() != nil {
+		log.Printf("Manager: Shutting down, sending match %s with incomplete details", msg.Match.GameID)
+	} else {
+		log.Printf("Manager: Match %s details still incomplete after %s, sending a provisional message", msg.Match.GameID, detailsDeadline)
+	}
+	messageID, err := discordClient.PostMatchResult(ctx, matchWithDetails)
+	if err != nil {
+		log.Printf("Discord: Error sending Discord webhook: %v", err)
+		mm.saveHistory(matchWithDetails)
+		return false
+	}
+
+	finalDetails, ready := mm.waitForDetails(ctx, waitCtx, msg.Match, detailsEditDeadline)
+	if finalDetails != nil {
+		matchWithDetails = parser.ParseMatchResultWithDetails(msg.Match, finalDetails, steamPlayers, settings.Config.Players)
+		matchWithDetails.Suspects = mm.suspects.Flag(matchWithDetails.EnemyTeam, profiles)
+	}
+	mm.saveHistory(matchWithDetails)
+
+	if !ready {
+		if waitCtx.Err() != nil {
+			log.Printf("Manager: Match %s message left provisional: %v", msg.Match.GameID, waitCtx.Err())
+		} else {
+			log.Printf("Manager: Match %s details never completed, message left provisional", msg.Match.GameID)
+		}
+		return true
+	}
+
+	if err := discordClient.EditMatchResult(ctx, messageID, matchWithDetails); err != nil {
+		log.Printf("Discord: Error editing Discord message: %v", err)
+	}
+	return true
+}
+
+// waitForDetails polls the source of the match until its details are
+// complete, the timeout is reached or waitCtx is done. The latest details
+// are returned either way, the boolean reports whether they are complete.
+func (mm *MatchNotifier) waitForDetails(ctx, waitCtx context.Context, match provider.Match, timeout time.Duration) (*provider.MatchDetails, bool) {
+	deadline := mm.clock.Now().Add(timeout)
+
+	var latest *provider.MatchDetails
+	for {
+		details, err := mm.sources.MatchDetails(ctx, match)
 		if err != nil {
-			// Continue without match details
-			log.Printf("Manager: Warning: failed to get match details: %v", err)
+			log.Printf("Manager: Match %s details not available yet: %v", match.GameID, err)
+		} else {
+			latest = details
+			if details.IsComplete(match) {
+				return details, true
+			}
 		}
-		matchWithDetails := parser.ParseMatchResultWithDetails(msg.Match, matchDetails, steamPlayers, mm.cfg.Players)
 
-		// Send Discord webhook
-		discordClient.SendMatchResult(matchWithDetails)
+		if mm.clock.Now().Add(detailsPollInterval).After(deadline) {
+			return latest, false
+		}
+		if mm.clock.Sleep(waitCtx, detailsPollInterval) != nil {
+			return latest, false
+		}
+	}
+}
+
+func (mm *MatchNotifier) saveHistory(match parser.MatchWithDetails) {
+	if err := mm.history.Save(match); err != nil {
+		log.Printf("Manager: Warning: failed to save match history: %v", err)
+	}
+	if err := mm.bans.Record(match); err != nil {
+		log.Printf("Manager: Warning: failed to record opponents: %v", err)
 	}
 }
 
 type SessionNotifier struct {
-	client        *leetify.LeetifyClient
-	cfg           *config.AppConfig
+	sources       *provider.Registry
+	settings      *LiveSettings
 	mistralClient *mistral.MistralClient
-	translations  locales.Translations
+	history       *history.Store
+	personas      *steam.PersonaCache
+	bans          *banwatch.Watcher
+	clock         clock.Clock
 	in            <-chan GameSession
 	withRank      bool
 }
 
 func NewSessionNotifier(
-	cfg *config.AppConfig,
-	leetifyClient *leetify.LeetifyClient,
+	settings *LiveSettings,
+	sources *provider.Registry,
 	mistralClient *mistral.MistralClient,
-	translations locales.Translations,
+	matchHistory *history.Store,
+	personas *steam.PersonaCache,
+	bans *banwatch.Watcher,
+	clk clock.Clock,
 	in <-chan GameSession,
 	withRank bool,
 ) *SessionNotifier {
 	return &SessionNotifier{
-		cfg:           cfg,
-		client:        leetifyClient,
+		settings:      settings,
+		sources:       sources,
 		mistralClient: mistralClient,
-		translations:  translations,
+		history:       matchHistory,
+		personas:      personas,
+		bans:          bans,
+		clock:         clk,
 		in:            in,
 		withRank:      withRank,
 	}
 }
 
-func (sn *SessionNotifier) HandleSession() {
+// HandleSession notifies every completed session until the input channel is closed
+func (sn *SessionNotifier) HandleSession(ctx context.Context) {
 	log.Println("SessionNotifier: Started sessionNotifier, waiting for completed sessions...")
 
-	discordClient := discord.NewWebhookClient(sn.cfg.DiscordHook, sn.mistralClient, sn.translations, sn.withRank)
-	steamClient := steam.NewSteamClient(sn.cfg.SteamAPIKey)
-
 	for completedSession := range sn.in {
 		log.Printf("SessionNotifier: New session received with %d matches", len(completedSession.Matches))
 
+		settings := sn.settings.Load()
+		discordClient := discord.NewWebhookClient(settings.Config.DiscordHook, sn.mistralClient, settings.Translations, sn.withRank)
+		steamClient := steam.NewSteamClient(settings.Config.SteamAPIKey, settings.Config.SteamAPIURL)
+
 		sessionWithDetails := parser.SessionWithDetails{
-			TrackedPlayers: sn.cfg.Players,
+			TrackedPlayers: settings.Config.Players,
 			IsFresh:        completedSession.IsFresh,
 		}
 
-		// Players flags are used for single match session only
-		var err error
-		steamPlayers := []steam.SteamPlayer{}
-		if len(completedSession.Matches) == 1 {
-			allSteamIDs := completedSession.GetSteamIDs()
-			steamPlayers, err = steamClient.GetSteamPlayers(allSteamIDs)
-			if err != nil {
-				// Continue without steam data
-				log.Printf("SessionNotifier: Warning: failed to get steam players: %v", err)
-			}
+		// Personas are mostly served from the cache, even for long sessions
+		allSteamIDs := completedSession.GetSteamIDs()
+		steamPlayers, err := sn.personas.Players(ctx, steamClient, allSteamIDs)
+		if err != nil {
+			// Continue without steam data
+			log.Printf("SessionNotifier: Warning: failed to get steam players: %v", err)
 		}
 
-		for i, game := range completedSession.Matches {
-			matchDetails, err := sn.client.GetMatchDetails(game.GameId)
+		for _, game := range completedSession.Matches {
+			matchDetails, err := sn.sources.MatchDetails(ctx, game)
 			if err != nil {
 				// Continue without match details
 				log.Printf("SessionNotifier: Warning: failed to get match details: %v", err)
 			}
 
-			matchWithDetails := parser.ParseMatchResultWithDetails(game, matchDetails, steamPlayers, sn.cfg.Players)
-			sessionWithDetails.Matches = append(sessionWithDetails.Matches, matchWithDetails)
-
-			// Avoid rate limit failure
-			if i < len(completedSession.Matches)-2 {
-				time.Sleep(3 * time.Minute)
+			matchWithDetails := parser.ParseMatchResultWithDetails(game, matchDetails, steamPlayers, settings.Config.Players)
+			if err := sn.history.Save(matchWithDetails); err != nil {
+				log.Printf("SessionNotifier: Warning: failed to save match history: %v", err)
 			}
+			if err := sn.bans.Record(matchWithDetails); err != nil {
+				log.Printf("SessionNotifier: Warning: failed to record opponents: %v", err)
+			}
+			sessionWithDetails.Matches = append(sessionWithDetails.Matches, matchWithDetails)
 		}
 
 		// sort matches by chronological order from oldest to newest
 		sessionWithDetails.SortMatchesByEndTime()
 
 		// Send Discord webhook
-		discordClient.SendSessionResult(sessionWithDetails)
+		if err := discordClient.SendSessionResult(ctx, sessionWithDetails); err != nil {
+			// The session stays checkpointed and is sent again after a restart
+			log.Printf("Discord: Error sending Discord webhook: %v", err)
+			continue
+		}
+		if err := completedSession.Acknowledge(); err != nil {
+			log.Printf("SessionNotifier: Error clearing session checkpoint: %v", err)
+		}
 	}
+
+	log.Println("SessionNotifier: Stopped")
 }
@@ -4,62 +4,65 @@ import (
 	"sort"
 	"time"
 
-	"github.com/mxdc/cs2-discord-bot/leetify"
+	"github.com/mxdc/cs2-discord-bot/provider"
 )
 
 type GameSession struct {
-	Matches           []leetify.LeetifyGameResponse
+	Matches           []provider.Match
 	LastMatchEndTime  time.Time
 	LastDetectionTime time.Time
 	sessionDuration   time.Duration
 	sessionTimeout    time.Duration
 	IsFresh           bool
 	debugMode         bool
+	// ack clears the session from the checkpoint once notified
+	ack func() error
 }
 
-func NewSession(game leetify.LeetifyGameResponse, detectedAt time.Time, debugMode bool) *GameSession {
-	matchEndTime, _ := time.Parse(time.RFC3339, game.GameFinishedAt)
+func NewSession(game provider.Match, detectedAt time.Time, debugMode bool) *GameSession {
+	s := newGameSession(debugMode)
+	s.Matches = []provider.Match{game}
+	s.LastMatchEndTime = game.GameFinishedAt
+	s.LastDetectionTime = detectedAt
+
+	return s
+}
 
+func newGameSession(debugMode bool) *GameSession {
 	return &GameSession{
-		Matches:           []leetify.LeetifyGameResponse{game},
-		LastMatchEndTime:  matchEndTime,
-		LastDetectionTime: detectedAt,
-		sessionDuration:   3*time.Hour + 15*time.Minute,
-		sessionTimeout:    3*time.Hour + 30*time.Minute,
-		IsFresh:           false,
-		debugMode:         debugMode,
+		Matches:         []provider.Match{},
+		sessionDuration: 3*time.Hour + 15*time.Minute,
+		sessionTimeout:  3*time.Hour + 30*time.Minute,
+		IsFresh:         false,
+		debugMode:       debugMode,
 	}
 }
 
-func (s *GameSession) AddMatch(game leetify.LeetifyGameResponse, detectedAt time.Time) {
+func (s *GameSession) AddMatch(game provider.Match, detectedAt time.Time) {
 	s.Matches = append(s.Matches, game)
 
 	// Sort matches chronologically from oldest to newest
 	sort.Slice(s.Matches, func(i, j int) bool {
-		timeI, _ := time.Parse(time.RFC3339, s.Matches[i].GameFinishedAt)
-		timeJ, _ := time.Parse(time.RFC3339, s.Matches[j].GameFinishedAt)
-		return timeI.Before(timeJ)
+		return s.Matches[i].GameFinishedAt.Before(s.Matches[j].GameFinishedAt)
 	})
 
 	if len(s.Matches) > 0 {
-		lastMatchTime, _ := time.Parse(time.RFC3339, s.Matches[len(s.Matches)-1].GameFinishedAt)
-		s.LastMatchEndTime = lastMatchTime
+		s.LastMatchEndTime = s.Matches[len(s.Matches)-1].GameFinishedAt
 	}
 
 	s.LastDetectionTime = detectedAt
 }
 
-func (s *GameSession) IsSessionTimeout() bool {
+func (s *GameSession) IsSessionTimeout(now time.Time) bool {
 	if s.debugMode {
-		return time.Since(s.LastMatchEndTime) > s.sessionTimeout
+		return now.Sub(s.LastMatchEndTime) > s.sessionTimeout
 	}
 
-	return time.Since(s.LastDetectionTime) > s.sessionTimeout
+	return now.Sub(s.LastDetectionTime) > s.sessionTimeout
 }
 
-func (s *GameSession) IsMatchPartOfSession(game leetify.LeetifyGameResponse) bool {
-	matchEndTime, _ := time.Parse(time.RFC3339, game.GameFinishedAt)
-	diff := matchEndTime.Sub(s.LastMatchEndTime).Abs()
+func (s *GameSession) IsMatchPartOfSession(game provider.Match) bool {
+	diff := game.GameFinishedAt.Sub(s.LastMatchEndTime).Abs()
 
 	return diff <= s.sessionDuration
 }
@@ -73,15 +76,24 @@ func (s *GameSession) GetSteamIDs() []string {
 	return allSteamIDs
 }
 
-func (s *GameSession) IsMatchBeforeCurrentSession(game leetify.LeetifyGameResponse) bool {
-	matchEndTime, _ := time.Parse(time.RFC3339, game.GameFinishedAt)
-	return matchEndTime.Before(s.LastMatchEndTime)
+func (s *GameSession) IsMatchBeforeCurrentSession(game provider.Match) bool {
+	return game.GameFinishedAt.Before(s.LastMatchEndTime)
 }
 
-func (s *GameSession) LastMatch() leetify.LeetifyGameResponse {
+func (s *GameSession) LastMatch() provider.Match {
 	if len(s.Matches) == 0 {
-		return leetify.LeetifyGameResponse{}
+		return provider.Match{}
 	}
 
 	return s.Matches[len(s.Matches)-1]
 }
+
+// Acknowledge records the session as notified, it is then removed from the
+// checkpoint and no longer sent again after a restart
+func (s *GameSession) Acknowledge() error {
+	if s.ack == nil {
+		return nil
+	}
+
+	return s.ack()
+}
@@ -0,0 +1,35 @@
+package session
+
+import (
+	"sync/atomic"
+
+	"github.com/mxdc/cs2-discord-bot/config"
+	"github.com/mxdc/cs2-discord-bot/locales"
+)
+
+// Settings are the notifier settings that can change while the bot runs
+type Settings struct {
+	Config       *config.AppConfig
+	Translations locales.Translations
+}
+
+// LiveSettings holds the current settings, swapped atomically on reload.
+// Notifiers load them once per notification so a reload never changes
+// the webhook or the language in the middle of a message.
+type LiveSettings struct {
+	current atomic.Pointer[Settings]
+}
+
+func NewLiveSettings(cfg *config.AppConfig, translations locales.Translations) *LiveSettings {
+	live := &LiveSettings{}
+	live.Store(cfg, translations)
+	return live
+}
+
+func (l *LiveSettings) Load() *Settings {
+	return l.current.Load()
+}
+
+func (l *LiveSettings) Store(cfg *config.AppConfig, translations locales.Translations) {
+	l.current.Store(&Settings{Config: cfg, Translations: translations})
+}
@@ -1,28 +1,58 @@
 package session
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
 	"time"
 
-	"github.com/mxdc/cs2-discord-bot/leetify"
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/provider"
+	"github.com/mxdc/cs2-discord-bot/storage"
 )
 
+// GameStore keeps track of the games that have already been announced
+type GameStore interface {
+	ShouldNotify(steamID string, game provider.Match) bool
+	AddGame(steamID string, game provider.Match)
+	MostRecentGame() SeenGame
+	// Restored reports whether the store contains games from a previous run
+	Restored() bool
+	// HasGames reports whether games were recorded for the player
+	HasGames(steamID string) bool
+	// Expired reports whether the game is older than the retention period,
+	// such games are no longer stored and must be considered as seen
+	Expired(game provider.Match) bool
+}
+
 type SeenGame struct {
-	SteamID        string
-	GameID         string
-	GameFinishedAt string
+	SteamID        string `json:"steamId"`
+	GameID         string `json:"gameId"`
+	GameFinishedAt string `json:"gameFinishedAt"`
 }
 
+// SeenGames is an in-memory GameStore, forgotten on restart
 type SeenGames struct {
+	mu    sync.RWMutex
 	games []SeenGame
 }
 
-func (sg *SeenGames) ShouldNotify(steamID string, game leetify.LeetifyGameResponse) bool {
-	gameID := game.GameId
+func NewSeenGames() *SeenGames {
+	return &SeenGames{games: []SeenGame{}}
+}
+
+func (sg *SeenGames) ShouldNotify(steamID string, game provider.Match) bool {
+	gameID := game.GameID
 
 	return sg.alreadyNotified(gameID) == false
 }
 
 func (sg *SeenGames) alreadyNotified(gameId string) bool {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+
 	for _, game := range sg.games {
 		if game.GameID == gameId {
 			return true
@@ -32,16 +62,22 @@ func (sg *SeenGames) alreadyNotified(gameId string) bool {
 	return false
 }
 
-func (sg *SeenGames) AddGame(steamID, gameID, gameFinishedAt string) {
+func (sg *SeenGames) AddGame(steamID string, game provider.Match) {
 	seenGame := SeenGame{
 		SteamID:        steamID,
-		GameID:         gameID,
-		GameFinishedAt: gameFinishedAt,
+		GameID:         game.GameID,
+		GameFinishedAt: game.GameFinishedAt.Format(time.RFC3339),
 	}
+
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
 	sg.games = append(sg.games, seenGame)
 }
 
 func (sg *SeenGames) MostRecentGame() SeenGame {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+
 	if len(sg.games) == 0 {
 		return SeenGame{}
 	}
@@ -63,3 +99,111 @@ func (sg *SeenGames) MostRecentGame() SeenGame {
 
 	return mostRecentGame
 }
+
+func (sg *SeenGames) Restored() bool {
+	return false
+}
+
+func (sg *SeenGames) HasGames(steamID string) bool {
+	sg.mu.RLock()
+	defer sg.mu.RUnlock()
+
+	for _, game := range sg.games {
+		if game.SteamID == steamID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expired is always false, the in-memory store never forgets games
+func (sg *SeenGames) Expired(game provider.Match) bool {
+	return false
+}
+
+// prune drops games finished before the given time.
+// Games with an unparsable end time are kept.
+func (sg *SeenGames) prune(before time.Time) {
+	kept := sg.games[:0]
+	for _, game := range sg.games {
+		gameTime, err := time.Parse(time.RFC3339, game.GameFinishedAt)
+		if err == nil && gameTime.Before(before) {
+			continue
+		}
+		kept = append(kept, game)
+	}
+	sg.games = kept
+}
+
+const seenGamesFileVersion = 1
+
+type seenGamesFile struct {
+	Version int        `json:"version"`
+	Games   []SeenGame `json:"games"`
+}
+
+// FileSeenGames is a GameStore persisted to a JSON file so announced games
+// survive restarts. Games older than the retention period are pruned.
+type FileSeenGames struct {
+	SeenGames
+	path      string
+	retention time.Duration
+	clock     clock.Clock
+	restored  bool
+}
+
+func NewFileSeenGames(path string, retention time.Duration, clk clock.Clock) (*FileSeenGames, error) {
+	store := &FileSeenGames{
+		SeenGames: SeenGames{games: []SeenGame{}},
+		path:      path,
+		retention: retention,
+		clock:     clk,
+	}
+
+	var file seenGamesFile
+	err := storage.ReadJSON(path, &file)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("Store: No seen games file at %s, starting empty", path)
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if file.Version != seenGamesFileVersion {
+		return nil, fmt.Errorf("unsupported seen games file version %d in %s", file.Version, path)
+	}
+
+	store.games = file.Games
+	store.restored = true
+	store.prune(store.cutoff())
+	log.Printf("Store: Restored %d seen games from %s", len(store.games), path)
+
+	return store, nil
+}
+
+func (fs *FileSeenGames) AddGame(steamID string, game provider.Match) {
+	fs.SeenGames.AddGame(steamID, game)
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.prune(fs.cutoff())
+	file := seenGamesFile{Version: seenGamesFileVersion, Games: fs.games}
+	if err := storage.WriteJSON(fs.path, file); err != nil {
+		log.Printf("Store: Error saving seen games: %v", err)
+	}
+}
+
+func (fs *FileSeenGames) Restored() bool {
+	return fs.restored
+}
+
+func (fs *FileSeenGames) Expired(game provider.Match) bool {
+	return game.GameFinishedAt.Before(fs.cutoff())
+}
+
+// cutoff returns the end time before which games are forgotten
+func (fs *FileSeenGames) cutoff() time.Time {
+	return fs.clock.Now().Add(-fs.retention)
+}
@@ -0,0 +1,91 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// PlayerBansResponse represents the GetPlayerBans response structure
+type PlayerBansResponse struct {
+	Players []struct {
+		SteamID          string `json:"SteamId"`
+		CommunityBanned  bool   `json:"CommunityBanned"`
+		VACBanned        bool   `json:"VACBanned"`
+		NumberOfVACBans  int    `json:"NumberOfVACBans"`
+		DaysSinceLastBan int    `json:"DaysSinceLastBan"`
+		NumberOfGameBans int    `json:"NumberOfGameBans"`
+		EconomyBan       string `json:"EconomyBan"`
+	} `json:"players"`
+}
+
+// PlayerBans represents the VAC and game bans of a player
+type PlayerBans struct {
+	SteamID         string
+	CommunityBanned bool
+	VACBans         int
+	GameBans        int
+	// DaysSinceLastBan is only meaningful when the player has a ban
+	DaysSinceLastBan int
+}
+
+// HasBans reports whether the player has a VAC or game ban
+func (b PlayerBans) HasBans() bool {
+	return b.VACBans > 0 || b.GameBans > 0
+}
+
+// GetPlayerBans gets the VAC and game bans of multiple players, with the
+// same batching and error handling as GetSteamPlayers
+func (c *Client) GetPlayerBans(ctx context.Context, steamIDs []string) ([]PlayerBans, error) {
+	var result []PlayerBans
+
+	validIDs := uniqueSteamIDs(steamIDs)
+	if len(validIDs) == 0 {
+		return result, nil
+	}
+	if len(c.apiKey) == 0 {
+		return result, ErrNoAPIKey
+	}
+
+	var errs []error
+	for batch := range slices.Chunk(validIDs, maxIDsPerRequest) {
+		bans, err := c.getPlayerBans(ctx, batch)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result = append(result, bans...)
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func (c *Client) getPlayerBans(ctx context.Context, steamIDs []string) ([]PlayerBans, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	u = u.JoinPath("ISteamUser", "GetPlayerBans", "v1/")
+	u.RawQuery = url.Values{"key": {c.apiKey}, "steamids": {strings.Join(steamIDs, ",")}}.Encode()
+
+	var data PlayerBansResponse
+	if err := c.getJSON(ctx, u.String(), &data); err != nil {
+		return nil, err
+	}
+
+	var bans []PlayerBans
+	for _, apiPlayer := range data.Players {
+		bans = append(bans, PlayerBans{
+			SteamID:          apiPlayer.SteamID,
+			CommunityBanned:  apiPlayer.CommunityBanned,
+			VACBans:          apiPlayer.NumberOfVACBans,
+			GameBans:         apiPlayer.NumberOfGameBans,
+			DaysSinceLastBan: apiPlayer.DaysSinceLastBan,
+		})
+	}
+
+	return bans, nil
+}
@@ -0,0 +1,180 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/storage"
+)
+
+// personaCacheVersion is bumped when SteamPlayer gains fields, personas
+// cached by a previous version are then fetched again
+const personaCacheVersion = 2
+
+// maxStaleness is how long an expired persona may still be served while it
+// is refreshed in the background. Older personas are fetched before use.
+const maxStaleness = 30 * 24 * time.Hour
+
+type cachedPersona struct {
+	Player    SteamPlayer `json:"player"`
+	FetchedAt time.Time   `json:"fetchedAt"`
+}
+
+type personaCacheFile struct {
+	Version  int                      `json:"version"`
+	Personas map[string]cachedPersona `json:"personas"`
+}
+
+// PersonaCache keeps the Steam personas of players (name, country, avatars)
+// so notifications do not call the Steam API for every match. Personas
+// older than the TTL are still served and refreshed in the background.
+// A nil cache calls the Steam API every time.
+type PersonaCache struct {
+	// path of the cache file, empty to keep it in memory
+	path  string
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu         sync.Mutex
+	personas   map[string]cachedPersona
+	refreshing map[string]bool
+}
+
+// NewPersonaCache loads the cache file at path, an empty path keeps the cache in memory
+func NewPersonaCache(path string, ttl time.Duration, clk clock.Clock) (*PersonaCache, error) {
+	cache := &PersonaCache{
+		path:       path,
+		ttl:        ttl,
+		clock:      clk,
+		personas:   map[string]cachedPersona{},
+		refreshing: map[string]bool{},
+	}
+
+	if len(path) == 0 {
+		return cache, nil
+	}
+
+	var file personaCacheFile
+	err := storage.ReadJSON(path, &file)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if file.Version < personaCacheVersion {
+		log.Printf("Steam: Discarding personas cached by version %d in %s", file.Version, path)
+		return cache, nil
+	}
+	if file.Version != personaCacheVersion {
+		return nil, fmt.Errorf("unsupported persona cache version %d in %s", file.Version, path)
+	}
+
+	for steamID, persona := range file.Personas {
+		cache.personas[steamID] = persona
+	}
+	log.Printf("Steam: Loaded %d cached personas from %s", len(cache.personas), path)
+
+	return cache, nil
+}
+
+// Players returns the personas of the given players. Missing and too old
+// personas are fetched with client, expired ones are returned right away
+// and refreshed in the background.
+func (c *PersonaCache) Players(ctx context.Context, client *Client, steamIDs []string) ([]SteamPlayer, error) {
+	if c == nil {
+		return client.GetSteamPlayers(ctx, steamIDs)
+	}
+
+	var result []SteamPlayer
+	var missing, stale []string
+
+	c.mu.Lock()
+	now := c.clock.Now()
+	for _, steamID := range uniqueSteamIDs(steamIDs) {
+		persona, found := c.personas[steamID]
+		age := now.Sub(persona.FetchedAt)
+
+		switch {
+		case !found || age > maxStaleness:
+			missing = append(missing, steamID)
+		case age > c.ttl:
+			result = append(result, persona.Player)
+			if !c.refreshing[steamID] {
+				c.refreshing[steamID] = true
+				stale = append(stale, steamID)
+			}
+		default:
+			result = append(result, persona.Player)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(stale) > 0 {
+		// The refresh outlives the notification that triggered it
+		go c.refresh(context.WithoutCancel(ctx), client, stale)
+	}
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	players, err := client.GetSteamPlayers(ctx, missing)
+	c.store(players)
+
+	return append(result, players...), err
+}
+
+func (c *PersonaCache) refresh(ctx context.Context, client *Client, steamIDs []string) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	players, err := client.GetSteamPlayers(ctx, steamIDs)
+	if err != nil {
+		log.Printf("Steam: Warning: failed to refresh cached personas: %v", err)
+	}
+	c.store(players)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, steamID := range steamIDs {
+		delete(c.refreshing, steamID)
+	}
+}
+
+// store records fetched personas and persists the cache
+func (c *PersonaCache) store(players []SteamPlayer) {
+	if len(players) == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	for _, player := range players {
+		c.personas[player.SteamID] = cachedPersona{Player: player, FetchedAt: now}
+	}
+
+	if len(c.path) == 0 {
+		return
+	}
+
+	// Personas too old to be served are dropped from the file
+	file := personaCacheFile{Version: personaCacheVersion, Personas: map[string]cachedPersona{}}
+	for steamID, persona := range c.personas {
+		if now.Sub(persona.FetchedAt) <= maxStaleness {
+			file.Personas[steamID] = persona
+		}
+	}
+
+	if err := storage.WriteJSON(c.path, file); err != nil {
+		log.Printf("Steam: Warning: failed to save persona cache: %v", err)
+	}
+}
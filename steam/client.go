@@ -1,11 +1,25 @@
 package steam
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+)
+
+const (
+	DefaultAPIURL = "https://api.steampowered.com"
+	// maxIDsPerRequest is the limit of GetPlayerSummaries
+	maxIDsPerRequest = 100
+	maxAttempts      = 3
+	retryDelay       = 2 * time.Second
 )
 
 // PlayerSummaryResponse represents the Steam API response structure
@@ -15,80 +29,176 @@ type PlayerSummaryResponse struct {
 			SteamID        string `json:"steamid"`
 			PersonaName    string `json:"personaname"`
 			LocCountryCode string `json:"loccountrycode"`
+			ProfileURL     string `json:"profileurl"`
+			Avatar         string `json:"avatar"`
+			AvatarMedium   string `json:"avatarmedium"`
+			AvatarFull     string `json:"avatarfull"`
+			TimeCreated    int64  `json:"timecreated"`
+			Visibility     int    `json:"communityvisibilitystate"`
 		} `json:"players"`
 	} `json:"response"`
 }
 
-// SteamPlayer represents a player's Steam ID, country code and persona name
+// SteamPlayer represents a player's Steam ID, country code, persona name and avatars
 type SteamPlayer struct {
-	SteamID     string
-	CountryCode string
-	PersonaName string
+	SteamID         string `json:"steamId"`
+	CountryCode     string `json:"countryCode"`
+	PersonaName     string `json:"personaName"`
+	ProfileURL      string `json:"profileUrl"`
+	AvatarURL       string `json:"avatarUrl"`
+	AvatarMediumURL string `json:"avatarMediumUrl"`
+	AvatarFullURL   string `json:"avatarFullUrl"`
+	// TimeCreated is the account creation date, zero when the profile hides it
+	TimeCreated int64 `json:"timeCreated"`
+	// Visibility is 1 for private profiles and 3 for public ones, zero when unknown
+	Visibility int `json:"visibility"`
 }
 
 // Client wraps the Steam Web API client
 type Client struct {
-	apiKey string
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
 }
 
-// NewSteamClient creates a new Steam API client
-func NewSteamClient(apiKey string) *Client {
+// NewSteamClient creates a new Steam API client, baseURL defaults to the Steam Web API
+func NewSteamClient(apiKey, baseURL string) *Client {
+	if len(baseURL) == 0 {
+		baseURL = DefaultAPIURL
+	}
+
 	return &Client{
-		apiKey: apiKey,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		baseURL: baseURL,
+		apiKey:  apiKey,
 	}
 }
 
-// GetSteamPlayers gets country codes and persona names for multiple players
-func (c *Client) GetSteamPlayers(steamIDs []string) ([]SteamPlayer, error) {
+// GetSteamPlayers gets country codes and persona names for multiple players.
+// IDs are de-duplicated and sent in batches of 100. When a batch fails, the
+// players of the other batches are returned along with the error.
+func (c *Client) GetSteamPlayers(ctx context.Context, steamIDs []string) ([]SteamPlayer, error) {
 	var result []SteamPlayer
 
-	if len(steamIDs) == 0 {
+	validIDs := uniqueSteamIDs(steamIDs)
+	if len(validIDs) == 0 {
 		return result, nil
 	}
+	if len(c.apiKey) == 0 {
+		return result, ErrNoAPIKey
+	}
 
-	// Filter out invalid IDs
-	var validIDs []string
-	for _, steamID := range steamIDs {
-		if _, err := strconv.ParseUint(steamID, 10, 64); err == nil {
-			validIDs = append(validIDs, steamID)
+	var errs []error
+	for batch := range slices.Chunk(validIDs, maxIDsPerRequest) {
+		players, err := c.getPlayerSummaries(ctx, batch)
+		if err != nil {
+			errs = append(errs, err)
+			continue
 		}
+		result = append(result, players...)
 	}
 
-	if len(validIDs) == 0 {
-		return result, nil
+	return result, errors.Join(errs...)
+}
+
+func (c *Client) getPlayerSummaries(ctx context.Context, steamIDs []string) ([]SteamPlayer, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	u = u.JoinPath("ISteamUser", "GetPlayerSummaries", "v2/")
+	u.RawQuery = url.Values{"key": {c.apiKey}, "steamids": {strings.Join(steamIDs, ",")}}.Encode()
+
+	var data PlayerSummaryResponse
+	if err := c.getJSON(ctx, u.String(), &data); err != nil {
+		return nil, err
+	}
+
+	var players []SteamPlayer
+	for _, apiPlayer := range data.Response.Players {
+		players = append(players, SteamPlayer{
+			SteamID:         apiPlayer.SteamID,
+			CountryCode:     apiPlayer.LocCountryCode,
+			PersonaName:     apiPlayer.PersonaName,
+			ProfileURL:      apiPlayer.ProfileURL,
+			AvatarURL:       apiPlayer.Avatar,
+			AvatarMediumURL: apiPlayer.AvatarMedium,
+			AvatarFullURL:   apiPlayer.AvatarFull,
+			TimeCreated:     apiPlayer.TimeCreated,
+			Visibility:      apiPlayer.Visibility,
+		})
+	}
+
+	return players, nil
+}
+
+// getJSON sends a GET request and decodes the response into v, retrying
+// rate limits, server errors and timeouts
+func (c *Client) getJSON(ctx context.Context, u string, v any) error {
+	for attempt := 1; ; attempt++ {
+		err := c.doGetJSON(ctx, u, v)
+		if err == nil || attempt >= maxAttempts || !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := retryDelay * time.Duration(attempt)
+		log.Printf("Steam: Request failed (%v), retrying in %s", err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
 	}
+}
 
-	// Call Steam API with comma-separated Steam IDs
-	steamIDsStr := strings.Join(validIDs, ",")
-	url := fmt.Sprintf(
-		"https://api.steampowered.com/ISteamUser/GetPlayerSummaries/v2/?key=%s&steamids=%s",
-		c.apiKey,
-		steamIDsStr,
-	)
+func (c *Client) doGetJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("steam: failed to create request: %w", err)
+	}
 
-	resp, err := http.Get(url)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return result, fmt.Errorf("Steam: Call failed: %v\n", err)
+		// The URL holds the API key, only keep the cause of the failure
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("steam: call failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return result, fmt.Errorf("Steam: Request failed with status: %d", resp.StatusCode)
+		return &APIError{StatusCode: resp.StatusCode}
 	}
 
-	var data PlayerSummaryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return result, fmt.Errorf("Steam: Failed to decode response: %v\n", err)
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("steam: failed to decode response: %w", err)
 	}
 
-	// Update result with actual data from API response
-	for _, apiPlayer := range data.Response.Players {
-		result = append(result, SteamPlayer{
-			SteamID:     apiPlayer.SteamID,
-			CountryCode: apiPlayer.LocCountryCode,
-			PersonaName: apiPlayer.PersonaName,
-		})
+	return nil
+}
+
+// uniqueSteamIDs drops duplicated and invalid Steam IDs, keeping the order
+func uniqueSteamIDs(steamIDs []string) []string {
+	seen := make(map[string]bool, len(steamIDs))
+
+	var ids []string
+	for _, steamID := range steamIDs {
+		if seen[steamID] {
+			continue
+		}
+		if _, err := strconv.ParseUint(steamID, 10, 64); err != nil {
+			continue
+		}
+		seen[steamID] = true
+		ids = append(ids, steamID)
 	}
 
-	return result, nil
+	return ids
 }
@@ -0,0 +1,56 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+var (
+	// ErrNoAPIKey is returned when no Steam API key is configured
+	ErrNoAPIKey = errors.New("steam: no API key configured")
+	// ErrUnauthorized is returned when Steam rejects the API key
+	ErrUnauthorized = errors.New("steam: invalid API key")
+	// ErrRateLimited is returned when Steam answers 429 Too Many Requests
+	ErrRateLimited = errors.New("steam: rate limited")
+	// ErrUnavailable is returned for 5xx responses
+	ErrUnavailable = errors.New("steam: service unavailable")
+)
+
+// APIError is returned when Steam answers with an unexpected status code.
+// It matches ErrUnauthorized, ErrRateLimited or ErrUnavailable with errors.Is.
+type APIError struct {
+	StatusCode int
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("steam: request failed with status: %d", e.StatusCode)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// isRetryable reports whether a request failing with err may succeed later
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrUnavailable) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,96 @@
+package steam
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// AppIDCS2 is the Steam app ID of Counter-Strike 2
+	AppIDCS2 = 730
+	// VisibilityPrivate and VisibilityPublic are the communityvisibilitystate values
+	VisibilityPrivate = 1
+	VisibilityPublic  = 3
+)
+
+type steamLevelResponse struct {
+	Response struct {
+		PlayerLevel *int `json:"player_level"`
+	} `json:"response"`
+}
+
+type ownedGamesResponse struct {
+	Response struct {
+		GameCount *int `json:"game_count"`
+		Games     []struct {
+			AppID           int `json:"appid"`
+			PlaytimeForever int `json:"playtime_forever"`
+		} `json:"games"`
+	} `json:"response"`
+}
+
+// GetSteamLevel returns the Steam level of a player, false when the profile hides it
+func (c *Client) GetSteamLevel(ctx context.Context, steamID string) (int, bool, error) {
+	if len(c.apiKey) == 0 {
+		return 0, false, ErrNoAPIKey
+	}
+
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	u = u.JoinPath("IPlayerService", "GetSteamLevel", "v1/")
+	u.RawQuery = url.Values{"key": {c.apiKey}, "steamid": {steamID}}.Encode()
+
+	var data steamLevelResponse
+	if err := c.getJSON(ctx, u.String(), &data); err != nil {
+		return 0, false, err
+	}
+
+	if data.Response.PlayerLevel == nil {
+		return 0, false, nil
+	}
+
+	return *data.Response.PlayerLevel, true, nil
+}
+
+// GetPlaytime returns the total playtime of a player in a game, false when
+// the game details of the profile are private
+func (c *Client) GetPlaytime(ctx context.Context, steamID string, appID int) (time.Duration, bool, error) {
+	if len(c.apiKey) == 0 {
+		return 0, false, ErrNoAPIKey
+	}
+
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	u = u.JoinPath("IPlayerService", "GetOwnedGames", "v1/")
+	u.RawQuery = url.Values{
+		"key":                       {c.apiKey},
+		"steamid":                   {steamID},
+		"include_played_free_games": {"1"},
+		"appids_filter[0]":          {strconv.Itoa(appID)},
+	}.Encode()
+
+	var data ownedGamesResponse
+	if err := c.getJSON(ctx, u.String(), &data); err != nil {
+		return 0, false, err
+	}
+
+	// Private game details return an empty response
+	if data.Response.GameCount == nil {
+		return 0, false, nil
+	}
+
+	for _, game := range data.Response.Games {
+		if game.AppID == appID {
+			return time.Duration(game.PlaytimeForever) * time.Minute, true, nil
+		}
+	}
+
+	return 0, true, nil
+}
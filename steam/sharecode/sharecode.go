@@ -0,0 +1,87 @@
+// Package sharecode decodes CS2 match share codes such as
+// CSGO-GADqf-jjyJ8-cSP2r-smZRo-TO2xK into the identifiers of the match demo.
+package sharecode
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	dictionary = "ABCDEFGHJKLMNOPQRSTUVWXYZabcdefhijkmnopqrstuvwxyz23456789"
+	prefix     = "CSGO-"
+	// codeLength is the number of characters once the prefix and dashes are removed
+	codeLength = 25
+	// byteLength is the size of the decoded match ID, outcome ID and token
+	byteLength = 18
+)
+
+// ErrInvalid is returned for strings that are not share codes
+var ErrInvalid = errors.New("sharecode: invalid share code")
+
+// ShareCode identifies the demo of a match
+type ShareCode struct {
+	// Code is the share code as given, e.g. CSGO-GADqf-jjyJ8-cSP2r-smZRo-TO2xK
+	Code      string
+	MatchID   uint64
+	OutcomeID uint64
+	Token     uint16
+}
+
+// Decode parses and validates a share code
+func Decode(code string) (ShareCode, error) {
+	if !strings.HasPrefix(code, prefix) {
+		return ShareCode{}, fmt.Errorf("%w: missing %q prefix", ErrInvalid, prefix)
+	}
+
+	groups := strings.Split(strings.TrimPrefix(code, prefix), "-")
+	if len(groups) != 5 {
+		return ShareCode{}, fmt.Errorf("%w: expected 5 groups", ErrInvalid)
+	}
+
+	chars := strings.Join(groups, "")
+	if len(chars) != codeLength {
+		return ShareCode{}, fmt.Errorf("%w: expected %d characters", ErrInvalid, codeLength)
+	}
+
+	// Characters are base 57 digits, least significant first
+	value := new(big.Int)
+	base := big.NewInt(int64(len(dictionary)))
+	for i := len(chars) - 1; i >= 0; i-- {
+		digit := strings.IndexByte(dictionary, chars[i])
+		if digit < 0 {
+			return ShareCode{}, fmt.Errorf("%w: unexpected character %q", ErrInvalid, chars[i])
+		}
+		value.Mul(value, base)
+		value.Add(value, big.NewInt(int64(digit)))
+	}
+
+	if value.BitLen() > byteLength*8 {
+		return ShareCode{}, fmt.Errorf("%w: value out of range", ErrInvalid)
+	}
+
+	var data [byteLength]byte
+	value.FillBytes(data[:])
+
+	return ShareCode{
+		Code:      code,
+		MatchID:   binary.LittleEndian.Uint64(data[0:8]),
+		OutcomeID: binary.LittleEndian.Uint64(data[8:16]),
+		Token:     binary.LittleEndian.Uint16(data[16:18]),
+	}, nil
+}
+
+// Valid reports whether code is a well formed share code
+func Valid(code string) bool {
+	_, err := Decode(code)
+	return err == nil
+}
+
+// ReplayLink returns the steam:// link that makes the CS2 client download
+// and open the demo of the match
+func (s ShareCode) ReplayLink() string {
+	return "steam://rungame/730/76561202255233023/+csgo_download_match%20" + s.Code
+}
@@ -0,0 +1,166 @@
+// Package steamid normalizes the ways a Steam account can be written, such as
+// STEAM_0:1:4491990, [U:1:8983981], 76561197969249709 or a profile URL, into
+// a Steam64 ID.
+package steamid
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// individualBase is the Steam64 ID of the account ID 0 of individual
+	// accounts in the public universe
+	individualBase = 76561197960265728
+	communityHost  = "steamcommunity.com"
+	// maxVanityLength is the longest custom URL Steam accepts
+	maxVanityLength = 32
+)
+
+var (
+	// ErrInvalid is returned for strings that are not Steam identifiers
+	ErrInvalid = errors.New("steamid: invalid Steam ID")
+
+	steamID2Pattern = regexp.MustCompile(`^STEAM_([0-5]):([01]):(\d+)$`)
+	steamID3Pattern = regexp.MustCompile(`^\[?U:1:(\d+)\]?$`)
+	vanityPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// ID is a parsed Steam identifier: either a Steam64 ID, or the vanity name
+// of a custom profile URL that still has to be resolved with the Steam API
+type ID struct {
+	SteamID64 uint64
+	Vanity    string
+}
+
+// IsVanity reports whether the ID is a vanity name
+func (id ID) IsVanity() bool {
+	return len(id.Vanity) > 0
+}
+
+// String returns the Steam64 ID in decimal, or the vanity name
+func (id ID) String() string {
+	if id.IsVanity() {
+		return id.Vanity
+	}
+
+	return strconv.FormatUint(id.SteamID64, 10)
+}
+
+// FromAccountID returns the Steam64 ID of an individual account ID
+func FromAccountID(accountID uint32) uint64 {
+	return individualBase + uint64(accountID)
+}
+
+// Parse accepts a SteamID64, SteamID2 (STEAM_0:1:4491990), SteamID3
+// ([U:1:8983981]), a steamcommunity.com/profiles/ URL or a
+// steamcommunity.com/id/ vanity URL
+func Parse(input string) (ID, error) {
+	s := strings.TrimSpace(input)
+	if len(s) == 0 {
+		return ID{}, fmt.Errorf("%w: empty", ErrInvalid)
+	}
+
+	if strings.Contains(s, communityHost) {
+		return parseURL(s)
+	}
+
+	if m := steamID2Pattern.FindStringSubmatch(s); m != nil {
+		// STEAM_X:Y:Z is the account ID Z*2+Y, X is the universe
+		y, _ := strconv.ParseUint(m[2], 10, 32)
+		z, err := strconv.ParseUint(m[3], 10, 31)
+		if err != nil {
+			return ID{}, fmt.Errorf("%w: %q account number out of range", ErrInvalid, input)
+		}
+		return ID{SteamID64: FromAccountID(uint32(z*2 + y))}, nil
+	}
+
+	if m := steamID3Pattern.FindStringSubmatch(s); m != nil {
+		accountID, err := strconv.ParseUint(m[1], 10, 32)
+		if err != nil {
+			return ID{}, fmt.Errorf("%w: %q account ID out of range", ErrInvalid, input)
+		}
+		return ID{SteamID64: FromAccountID(uint32(accountID))}, nil
+	}
+
+	steamID64, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return ID{}, fmt.Errorf("%w: %q is not a SteamID64, SteamID2, SteamID3 or steamcommunity.com URL", ErrInvalid, input)
+	}
+	if steamID64 < individualBase || steamID64-individualBase > 1<<32-1 {
+		return ID{}, fmt.Errorf("%w: %q is not the SteamID64 of an individual account", ErrInvalid, input)
+	}
+
+	return ID{SteamID64: steamID64}, nil
+}
+
+// ParseVanity accepts a vanity name or a steamcommunity.com/id/ URL and
+// returns the vanity name
+func ParseVanity(input string) (string, error) {
+	s := strings.TrimSpace(input)
+	if !strings.Contains(s, communityHost) {
+		if err := checkVanity(s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+
+	id, err := parseURL(s)
+	if err != nil {
+		return "", err
+	}
+	if !id.IsVanity() {
+		return "", fmt.Errorf("%w: %q is a profile URL, not a vanity URL", ErrInvalid, input)
+	}
+
+	return id.Vanity, nil
+}
+
+// parseURL reads steamcommunity.com/profiles/<id> and steamcommunity.com/id/<name>
+func parseURL(input string) (ID, error) {
+	s := input
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return ID{}, fmt.Errorf("%w: %q is not a valid URL", ErrInvalid, input)
+	}
+	if strings.TrimPrefix(u.Hostname(), "www.") != communityHost {
+		return ID{}, fmt.Errorf("%w: %q is not a %s URL", ErrInvalid, input, communityHost)
+	}
+
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) < 2 {
+		return ID{}, fmt.Errorf("%w: %q has no profile", ErrInvalid, input)
+	}
+
+	switch segments[0] {
+	case "profiles":
+		id, err := Parse(segments[1])
+		if err != nil || id.IsVanity() {
+			return ID{}, fmt.Errorf("%w: %q has an invalid profile ID", ErrInvalid, input)
+		}
+		return id, nil
+	case "id":
+		if err := checkVanity(segments[1]); err != nil {
+			return ID{}, err
+		}
+		return ID{Vanity: segments[1]}, nil
+	default:
+		return ID{}, fmt.Errorf("%w: %q is not a /profiles/ or /id/ URL", ErrInvalid, input)
+	}
+}
+
+func checkVanity(name string) error {
+	if len(name) == 0 || len(name) > maxVanityLength || !vanityPattern.MatchString(name) {
+		return fmt.Errorf("%w: %q is not a valid vanity name", ErrInvalid, name)
+	}
+
+	return nil
+}
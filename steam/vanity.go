@@ -0,0 +1,127 @@
+package steam
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/mxdc/cs2-discord-bot/storage"
+)
+
+// ErrVanityNotFound is returned when no Steam account uses a vanity name
+var ErrVanityNotFound = errors.New("steam: no account with this vanity name")
+
+type resolveVanityResponse struct {
+	Response struct {
+		SteamID string `json:"steamid"`
+		Success int    `json:"success"`
+		Message string `json:"message"`
+	} `json:"response"`
+}
+
+// ResolveVanityURL returns the Steam64 ID of the account using the vanity
+// name, as in https://steamcommunity.com/id/<vanityName>
+func (c *Client) ResolveVanityURL(ctx context.Context, vanityName string) (string, error) {
+	if len(c.apiKey) == 0 {
+		return "", ErrNoAPIKey
+	}
+
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	u = u.JoinPath("ISteamUser", "ResolveVanityURL", "v1/")
+	u.RawQuery = url.Values{"key": {c.apiKey}, "vanityurl": {vanityName}}.Encode()
+
+	var data resolveVanityResponse
+	if err := c.getJSON(ctx, u.String(), &data); err != nil {
+		return "", err
+	}
+
+	// success is 1 on a match and 42 when no account uses the name
+	if data.Response.Success != 1 || len(data.Response.SteamID) == 0 {
+		return "", ErrVanityNotFound
+	}
+
+	return data.Response.SteamID, nil
+}
+
+type vanityCacheFile struct {
+	Version  int               `json:"version"`
+	SteamIDs map[string]string `json:"steamIds"`
+}
+
+const vanityCacheVersion = 1
+
+// VanityCache remembers the Steam64 ID of vanity names, so a restart does
+// not depend on the Steam API
+type VanityCache struct {
+	// path of the cache file, empty to keep it in memory
+	path string
+
+	mu       sync.Mutex
+	steamIDs map[string]string
+}
+
+// NewVanityCache loads the cache file at path, an empty path keeps the cache in memory
+func NewVanityCache(path string) (*VanityCache, error) {
+	cache := &VanityCache{
+		path:     path,
+		steamIDs: map[string]string{},
+	}
+
+	if len(path) == 0 {
+		return cache, nil
+	}
+
+	var file vanityCacheFile
+	err := storage.ReadJSON(path, &file)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if file.Version != vanityCacheVersion {
+		return nil, fmt.Errorf("unsupported vanity cache version %d in %s", file.Version, path)
+	}
+
+	for name, steamID := range file.SteamIDs {
+		cache.steamIDs[name] = steamID
+	}
+
+	return cache, nil
+}
+
+// Resolve returns the Steam64 ID of a vanity name, from the cache when known
+func (c *VanityCache) Resolve(ctx context.Context, client *Client, vanityName string) (string, error) {
+	c.mu.Lock()
+	steamID, found := c.steamIDs[vanityName]
+	c.mu.Unlock()
+	if found {
+		return steamID, nil
+	}
+
+	steamID, err := client.ResolveVanityURL(ctx, vanityName)
+	if err != nil {
+		return "", err
+	}
+	log.Printf("Steam: Resolved %s to %s", vanityName, steamID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.steamIDs[vanityName] = steamID
+	if len(c.path) > 0 {
+		file := vanityCacheFile{Version: vanityCacheVersion, SteamIDs: c.steamIDs}
+		if err := storage.WriteJSON(c.path, file); err != nil {
+			log.Printf("Steam: Warning: failed to save vanity cache: %v", err)
+		}
+	}
+
+	return steamID, nil
+}
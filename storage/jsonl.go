@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// AppendJSONLine appends the JSON encoding of v as a single line to the file at path.
+// The file and its parent directories are created when missing.
+func AppendJSONLine(path string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s record: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to append to %s: %w", path, err)
+	}
+
+	return f.Sync()
+}
+
+// ReadJSONLines calls fn with every line of the file at path. Lines fn
+// fails to decode are logged and skipped. A process killed while appending
+// leaves a partial last line: it is cut from the file so the next append
+// starts on a fresh line.
+// The returned error wraps os.ErrNotExist when the file does not exist yet.
+func ReadJSONLines(path string, fn func(line []byte) error) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReaderSize(f, 64*1024)
+
+	var offset, lastGood int64
+	lineNumber := 0
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("failed to read %s: %w", path, readErr)
+		}
+		if len(line) == 0 {
+			break
+		}
+
+		lineNumber++
+		offset += int64(len(line))
+		complete := line[len(line)-1] == '\n'
+
+		record := bytes.TrimSpace(line)
+		if len(record) == 0 {
+			lastGood = offset
+			continue
+		}
+
+		if err := fn(record); err != nil {
+			if !complete {
+				break
+			}
+			log.Printf("Storage: Warning: skipping invalid record %s:%d: %v", path, lineNumber, err)
+			lastGood = offset
+			continue
+		}
+
+		if !complete {
+			// The record was written but not its line break
+			if _, err := f.WriteAt([]byte{'\n'}, offset); err != nil {
+				return fmt.Errorf("failed to repair %s: %w", path, err)
+			}
+			offset++
+		}
+		lastGood = offset
+	}
+
+	if lastGood < offset {
+		log.Printf("Storage: Warning: truncating partial record at the end of %s:%d", path, lineNumber)
+		if err := f.Truncate(lastGood); err != nil {
+			return fmt.Errorf("failed to truncate %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+// Package suspects flags the opponents whose Steam profile looks unusual
+// for their performance in a match: brand new accounts with an absurd K/D,
+// private profiles and prior bans.
+package suspects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/mxdc/cs2-discord-bot/clock"
+	"github.com/mxdc/cs2-discord-bot/parser"
+	"github.com/mxdc/cs2-discord-bot/steam"
+)
+
+const (
+	// newAccountAge is the age under which an account is considered new
+	newAccountAge = 90 * 24 * time.Hour
+	// lowPlaytime is the CS2 playtime under which an account is considered new
+	lowPlaytime = 100 * time.Hour
+	// lowSteamLevel is the Steam level under which an account is considered new
+	lowSteamLevel = 5
+	// highKdRatio and minKills define an outstanding performance, new
+	// accounts are only flagged when they reach both
+	highKdRatio = 2.0
+	minKills    = 15
+)
+
+// Profile is what Steam tells about an opponent, unknown values are left
+// to zero with their Known flag unset
+type Profile struct {
+	SteamID        string
+	Private        bool
+	AccountCreated time.Time
+	SteamLevel     int
+	LevelKnown     bool
+	CS2Playtime    time.Duration
+	PlaytimeKnown  bool
+	Bans           steam.PlayerBans
+}
+
+// Detector looks up the Steam profiles of opponents and flags the suspicious
+// ones. A nil detector flags nobody and never calls Steam.
+type Detector struct {
+	clock clock.Clock
+}
+
+func NewDetector(clk clock.Clock) *Detector {
+	return &Detector{clock: clk}
+}
+
+// Profiles fetches the Steam level, CS2 playtime and bans of the players.
+// The account age and visibility are read from the Steam personas. Profiles
+// are returned even when some calls failed, along with the errors.
+func (d *Detector) Profiles(ctx context.Context, client *steam.Client, steamPlayers []steam.SteamPlayer, steamIDs []string) (map[string]Profile, error) {
+	if d == nil || len(steamIDs) == 0 {
+		return nil, nil
+	}
+
+	profiles := make(map[string]Profile, len(steamIDs))
+	for _, steamID := range steamIDs {
+		profiles[steamID] = Profile{SteamID: steamID}
+	}
+
+	for _, sp := range steamPlayers {
+		profile, found := profiles[sp.SteamID]
+		if !found {
+			continue
+		}
+		profile.Private = sp.Visibility == steam.VisibilityPrivate
+		if sp.TimeCreated > 0 {
+			profile.AccountCreated = time.Unix(sp.TimeCreated, 0)
+		}
+		profiles[sp.SteamID] = profile
+	}
+
+	var errs []error
+
+	bans, err := client.GetPlayerBans(ctx, steamIDs)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, b := range bans {
+		if profile, found := profiles[b.SteamID]; found {
+			profile.Bans = b
+			profiles[b.SteamID] = profile
+		}
+	}
+
+	for steamID, profile := range profiles {
+		// Private profiles hide their level and games
+		if profile.Private {
+			continue
+		}
+
+		profile.SteamLevel, profile.LevelKnown, err = client.GetSteamLevel(ctx, steamID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("steam level of %s: %w", steamID, err))
+		}
+		profile.CS2Playtime, profile.PlaytimeKnown, err = client.GetPlaytime(ctx, steamID, steam.AppIDCS2)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("playtime of %s: %w", steamID, err))
+		}
+		profiles[steamID] = profile
+	}
+
+	return profiles, errors.Join(errs...)
+}
+
+// Flag returns the players of the team that look suspicious, highest K/D first
+func (d *Detector) Flag(team parser.Team, profiles map[string]Profile) []parser.Suspect {
+	if d == nil {
+		return nil
+	}
+
+	var suspects []parser.Suspect
+	for _, player := range team.Players {
+		profile, found := profiles[player.SteamID]
+		if !found {
+			continue
+		}
+
+		reasons := d.reasons(player, profile)
+		if len(reasons) == 0 {
+			continue
+		}
+
+		suspects = append(suspects, parser.Suspect{
+			SteamID: player.SteamID,
+			Name:    player.Name,
+			KdRatio: kdRatio(player),
+			Reasons: reasons,
+		})
+	}
+
+	slices.SortFunc(suspects, func(a, b parser.Suspect) int {
+		if a.KdRatio > b.KdRatio {
+			return -1
+		}
+		if a.KdRatio < b.KdRatio {
+			return 1
+		}
+		return 0
+	})
+
+	return suspects
+}
+
+func (d *Detector) reasons(player parser.Player, profile Profile) []string {
+	var reasons []string
+
+	// A new account is only unusual when it plays far above the lobby
+	if kdRatio(player) >= highKdRatio && player.Kills >= minKills {
+		if !profile.AccountCreated.IsZero() {
+			age := d.clock.Now().Sub(profile.AccountCreated)
+			if age < newAccountAge {
+				reasons = append(reasons, fmt.Sprintf("account created %d days ago", int(age.Hours()/24)))
+			}
+		}
+		if profile.PlaytimeKnown && profile.CS2Playtime < lowPlaytime {
+			reasons = append(reasons, fmt.Sprintf("%dh of CS2", int(profile.CS2Playtime.Hours())))
+		}
+		if profile.LevelKnown && profile.SteamLevel < lowSteamLevel {
+			reasons = append(reasons, fmt.Sprintf("Steam level %d", profile.SteamLevel))
+		}
+	}
+
+	if profile.Private {
+		reasons = append(reasons, "private profile")
+	}
+
+	if profile.Bans.VACBans > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d VAC ban(s)", profile.Bans.VACBans))
+	}
+	if profile.Bans.GameBans > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d game ban(s)", profile.Bans.GameBans))
+	}
+	if profile.Bans.HasBans() {
+		reasons = append(reasons, fmt.Sprintf("last ban %d days ago", profile.Bans.DaysSinceLastBan))
+	}
+
+	return reasons
+}
+
+// kdRatio returns the K/D of the player, computed from the kills and deaths
+// when the source does not provide it
+func kdRatio(player parser.Player) float64 {
+	if player.KdRatio > 0 {
+		return player.KdRatio
+	}
+	if player.Deaths == 0 {
+		return float64(player.Kills)
+	}
+
+	return float64(player.Kills) / float64(player.Deaths)
+}